@@ -0,0 +1,195 @@
+package tgr
+
+import (
+	"fmt"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// defaultChatAdminCacheTTL 管理员身份查询结果默认缓存时长
+const defaultChatAdminCacheTTL = 5 * time.Minute
+
+// defaultChatAdminDeniedMessage 非管理员调用时默认回复的内容
+const defaultChatAdminDeniedMessage = "This action is for chat administrators only."
+
+// ChatAdminPermission 管理员权限，取值与 Bot API 的 ChatMember 字段一致
+type ChatAdminPermission string
+
+const (
+	CanManageChat       ChatAdminPermission = "can_manage_chat"
+	CanChangeInfo       ChatAdminPermission = "can_change_info"
+	CanPostMessages     ChatAdminPermission = "can_post_messages"
+	CanEditMessages     ChatAdminPermission = "can_edit_messages"
+	CanDeleteMessages   ChatAdminPermission = "can_delete_messages"
+	CanInviteUsers      ChatAdminPermission = "can_invite_users"
+	CanRestrictMembers  ChatAdminPermission = "can_restrict_members"
+	CanPinMessages      ChatAdminPermission = "can_pin_messages"
+	CanPromoteMembers   ChatAdminPermission = "can_promote_members"
+	CanManageVoiceChats ChatAdminPermission = "can_manage_voice_chats"
+)
+
+// granted 判断成员是否拥有该权限，群主拥有全部权限
+func (p ChatAdminPermission) granted(m tgbotapi.ChatMember) bool {
+	if m.IsCreator() {
+		return true
+	}
+	if !m.IsAdministrator() {
+		return false
+	}
+	switch p {
+	case CanManageChat:
+		return m.CanManageChat
+	case CanChangeInfo:
+		return m.CanChangeInfo
+	case CanPostMessages:
+		return m.CanPostMessages
+	case CanEditMessages:
+		return m.CanEditMessages
+	case CanDeleteMessages:
+		return m.CanDeleteMessages
+	case CanInviteUsers:
+		return m.CanInviteUsers
+	case CanRestrictMembers:
+		return m.CanRestrictMembers
+	case CanPinMessages:
+		return m.CanPinMessages
+	case CanPromoteMembers:
+		return m.CanPromoteMembers
+	case CanManageVoiceChats:
+		return m.CanManageVoiceChats
+	}
+	return false
+}
+
+// RequireChatAdmin 返回只允许群组管理员继续执行的中间件，perms 为额外要求的管理员权限。
+// 成员信息通过 getChatMember 查询，按聊天和用户缓存（默认 5 分钟，见 SetChatAdminCacheTTL）；
+// 收到 chat_member 更新时对应的缓存会失效。
+// 私聊、无法确定聊天或发送者的更新一律拒绝。拒绝时回复 SetChatAdminDeniedMessage 设置的内容
+// （回调查询以弹窗提示），内容为空时静默中断。
+// 以群组身份匿名发言的管理员只在未要求额外权限时放行。
+//
+// 命令参数在分组中间件之后、每个处理函数之前解析，作为处理函数传给带参数的命令时，
+// 参数有误的非管理员会先收到用法提示；应通过 Group 或 Use 作为分组中间件使用，使校验先于参数解析。
+//
+// 示例:
+//
+//	admins := router.Groups().Group(tgr.RequireChatAdmin(tgr.CanRestrictMembers))
+//	admins.Command("ban :user_id:int", banHandler)
+func RequireChatAdmin(perms ...ChatAdminPermission) HandlerFunc {
+	return func(c *Context) {
+		if c.isChatAdmin(perms) {
+			c.Next()
+			return
+		}
+		c.denyChatAdmin()
+		c.Abort()
+	}
+}
+
+// isChatAdmin 判断当前更新的发送者是否为拥有指定权限的管理员
+func (c *Context) isChatAdmin(perms []ChatAdminPermission) bool {
+	chat := updateChat(c.Update)
+	if chat == nil || chat.IsPrivate() {
+		return false
+	}
+	// 匿名管理员以群组身份发言，无法查询其具体权限
+	if msg := updateMessage(c.Update); msg != nil && msg.SenderChat != nil && msg.SenderChat.ID == chat.ID {
+		return len(perms) == 0
+	}
+	from := c.Update.SentFrom()
+	if from == nil {
+		return false
+	}
+	member, err := c.router.chatMember(chat.ID, from.ID)
+	if err != nil {
+		if c.Logger != nil {
+			c.Logger.Printf("查询群组成员失败: %v", err)
+		}
+		return false
+	}
+	if !member.IsCreator() && !member.IsAdministrator() {
+		return false
+	}
+	for _, p := range perms {
+		if !p.granted(member) {
+			return false
+		}
+	}
+	return true
+}
+
+// denyChatAdmin 向非管理员回复拒绝信息
+func (c *Context) denyChatAdmin() {
+	c.router.mu.RLock()
+	text := c.router.chatAdminDeniedMessage
+	c.router.mu.RUnlock()
+	if text == "" {
+		return
+	}
+	if c.CallbackQuery != nil {
+		if err := c.AnswerCallback(AnswerCallbackOptions{Text: text, ShowAlert: true}); err != nil && c.Logger != nil {
+			c.Logger.Printf("回复管理员校验结果失败: %v", err)
+		}
+		return
+	}
+	if b := c.Reply(text); b != nil {
+		if _, err := b.Send(); err != nil && c.Logger != nil {
+			c.Logger.Printf("回复管理员校验结果失败: %v", err)
+		}
+	}
+}
+
+// chatAdminKey 管理员缓存键
+func chatAdminKey(chatID, userID int64) string {
+	return fmt.Sprintf("%d:%d", chatID, userID)
+}
+
+// chatMember 查询群组成员信息，结果按聊天和用户缓存
+func (t *TelegramRouter) chatMember(chatID, userID int64) (tgbotapi.ChatMember, error) {
+	key := chatAdminKey(chatID, userID)
+	if v, ok := t.chatAdminCache.get(key); ok {
+		return v.(tgbotapi.ChatMember), nil
+	}
+	if t.Bot == nil {
+		return tgbotapi.ChatMember{}, fmt.Errorf("bot is not configured")
+	}
+	member, err := t.Bot.GetChatMember(tgbotapi.GetChatMemberConfig{
+		ChatConfigWithUser: tgbotapi.ChatConfigWithUser{ChatID: chatID, UserID: userID},
+	})
+	if err != nil {
+		return tgbotapi.ChatMember{}, err
+	}
+	t.mu.RLock()
+	ttl := t.chatAdminCacheTTL
+	t.mu.RUnlock()
+	t.chatAdminCache.set(key, member, ttl)
+	return member, nil
+}
+
+// forgetChatMember 收到 chat_member 更新时使对应的管理员缓存失效
+func (t *TelegramRouter) forgetChatMember(update *tgbotapi.Update) {
+	if u := update.ChatMember; u != nil && u.NewChatMember.User != nil {
+		t.chatAdminCache.delete(chatAdminKey(u.Chat.ID, u.NewChatMember.User.ID))
+	}
+}
+
+// SetChatAdminCacheTTL 设置 RequireChatAdmin 查询结果的缓存时长，默认 5 分钟。
+// ttl <= 0 时使用默认值。
+func (t *TelegramRouter) SetChatAdminCacheTTL(ttl time.Duration) *TelegramRouter {
+	if ttl <= 0 {
+		ttl = defaultChatAdminCacheTTL
+	}
+	t.mu.Lock()
+	t.chatAdminCacheTTL = ttl
+	t.mu.Unlock()
+	return t
+}
+
+// SetChatAdminDeniedMessage 设置 RequireChatAdmin 拒绝时回复的内容，为空时静默中断
+func (t *TelegramRouter) SetChatAdminDeniedMessage(text string) *TelegramRouter {
+	t.mu.Lock()
+	t.chatAdminDeniedMessage = text
+	t.mu.Unlock()
+	return t
+}
@@ -0,0 +1,147 @@
+package tgr
+
+import (
+	"testing"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// adminChat 测试使用的超级群组
+func adminChat() *tgbotapi.Chat {
+	return &tgbotapi.Chat{ID: -100, Type: "supergroup"}
+}
+
+func TestRequireChatAdmin(t *testing.T) {
+	creator := tgbotapi.ChatMember{Status: "creator"}
+	admin := tgbotapi.ChatMember{Status: "administrator", CanRestrictMembers: true}
+	limited := tgbotapi.ChatMember{Status: "administrator", CanPinMessages: true}
+	member := tgbotapi.ChatMember{Status: "member"}
+
+	groupText := func() *tgbotapi.Update {
+		u := textUpdate("/ban 1")
+		u.Message.Chat = adminChat()
+		return u
+	}
+	anonymous := func() *tgbotapi.Update {
+		u := groupText()
+		u.Message.From = &tgbotapi.User{ID: 1087968824, UserName: "GroupAnonymousBot"}
+		u.Message.SenderChat = adminChat()
+		return u
+	}
+	groupCallback := func() *tgbotapi.Update {
+		u := callbackUpdate("ban/1")
+		u.CallbackQuery.ID = "q"
+		u.CallbackQuery.Message.Chat = adminChat()
+		return u
+	}
+
+	tests := []struct {
+		name      string
+		member    *tgbotapi.ChatMember // 缓存中用户 7 的成员信息
+		perms     []ChatAdminPermission
+		update    *tgbotapi.Update
+		silent    bool // 拒绝信息为空，静默中断
+		wantAllow bool
+		wantReply string // 拒绝时回复使用的 Bot API 方法
+	}{
+		{name: "creator", member: &creator, perms: []ChatAdminPermission{CanRestrictMembers}, update: groupText(), wantAllow: true},
+		{name: "admin with permission", member: &admin, perms: []ChatAdminPermission{CanRestrictMembers}, update: groupText(), wantAllow: true},
+		{name: "admin without extra permissions", member: &limited, update: groupText(), wantAllow: true},
+		{name: "admin missing permission", member: &limited, perms: []ChatAdminPermission{CanRestrictMembers}, update: groupText(), wantReply: "sendMessage"},
+		{name: "member", member: &member, update: groupText(), wantReply: "sendMessage"},
+		{name: "private chat", member: &creator, update: textUpdate("/ban 1"), wantReply: "sendMessage"},
+		{name: "anonymous admin", update: anonymous(), wantAllow: true},
+		{name: "anonymous admin with permissions", perms: []ChatAdminPermission{CanRestrictMembers}, update: anonymous(), wantReply: "sendMessage"},
+		{name: "callback answered with alert", member: &member, update: groupCallback(), wantReply: "answerCallbackQuery"},
+		{name: "silent denial", member: &member, update: groupText(), silent: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bot, api := newFakeBot()
+			api.results["getChatMember"] = "" // 只能从缓存读取
+			router := NewTelegramRouter(bot)
+			router.Logger = nil
+			if tt.silent {
+				router.SetChatAdminDeniedMessage("")
+			}
+			if tt.member != nil {
+				router.chatAdminCache.set(chatAdminKey(-100, 7), *tt.member, time.Minute)
+				router.chatAdminCache.set(chatAdminKey(1, 7), *tt.member, time.Minute)
+			}
+			allowed := false
+			guard := RequireChatAdmin(tt.perms...)
+			router.OnUpdate(guard, func(c *Context) { allowed = true })
+			router.HandleUpdate(tt.update)
+
+			if allowed != tt.wantAllow {
+				t.Errorf("allowed = %v, want %v", allowed, tt.wantAllow)
+			}
+			var replies []fakeRequest
+			for _, r := range api.calls() {
+				if r.method != "getChatMember" {
+					replies = append(replies, r)
+				}
+			}
+			if tt.wantReply == "" {
+				if len(replies) != 0 {
+					t.Errorf("unexpected replies %+v", replies)
+				}
+				return
+			}
+			if len(replies) != 1 || replies[0].method != tt.wantReply {
+				t.Fatalf("replies %+v, want one %s", replies, tt.wantReply)
+			}
+			params := replies[0].params
+			switch tt.wantReply {
+			case "sendMessage":
+				if params.Get("text") != defaultChatAdminDeniedMessage {
+					t.Errorf("reply text %q", params.Get("text"))
+				}
+			case "answerCallbackQuery":
+				if params.Get("text") != defaultChatAdminDeniedMessage || params.Get("show_alert") != "true" {
+					t.Errorf("callback answer %v", params)
+				}
+			}
+		})
+	}
+}
+
+func TestRequireChatAdminCacheInvalidation(t *testing.T) {
+	bot, api := newFakeBot()
+	api.results["getChatMember"] = `{"user":{"id":7},"status":"member"}`
+	router := NewTelegramRouter(bot).SetChatAdminDeniedMessage("")
+	router.chatAdminCache.set(chatAdminKey(-100, 7), tgbotapi.ChatMember{Status: "administrator"}, time.Minute)
+
+	var allowed []bool
+	router.Group(RequireChatAdmin()).Command("ban", func(c *Context) { allowed = append(allowed, true) })
+	router.NoRoute(func(c *Context) {})
+	ban := func() *tgbotapi.Update {
+		u := commandUpdate("/ban")
+		u.Message.Chat = adminChat()
+		return u
+	}
+
+	router.HandleUpdate(ban())
+	// 管理员被降级：chat_member 更新使缓存失效，下一次重新查询
+	router.HandleUpdate(&tgbotapi.Update{ChatMember: &tgbotapi.ChatMemberUpdated{
+		Chat:          *adminChat(),
+		From:          tgbotapi.User{ID: 1},
+		OldChatMember: tgbotapi.ChatMember{User: &tgbotapi.User{ID: 7}, Status: "administrator"},
+		NewChatMember: tgbotapi.ChatMember{User: &tgbotapi.User{ID: 7}, Status: "member"},
+	}})
+	if _, ok := router.chatAdminCache.get(chatAdminKey(-100, 7)); ok {
+		t.Fatal("cache entry survived the chat_member update")
+	}
+	router.HandleUpdate(ban())
+
+	if len(allowed) != 1 {
+		t.Errorf("allowed %d times, want 1", len(allowed))
+	}
+	if calls := api.calls(); len(calls) != 1 || calls[0].method != "getChatMember" {
+		t.Errorf("requests %+v, want one getChatMember", calls)
+	}
+	if _, ok := router.chatAdminCache.get(chatAdminKey(-100, 7)); !ok {
+		t.Error("lookup result not cached")
+	}
+}
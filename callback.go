@@ -0,0 +1,136 @@
+package tgr
+
+import "strings"
+
+// callbackNode 回调路由前缀树节点，按 "/" 分隔的段组织。
+// 匹配优先级：静态段 > 参数段（:name）> 通配符（*）。
+type callbackNode struct {
+	static   map[string]*callbackNode
+	param    *callbackNode
+	wildcard *callbackNode
+	routes   []*CallbackRoute // 在此节点结束的路由，按注册顺序排列
+}
+
+// callbackMatch 一次回调路由匹配结果
+type callbackMatch struct {
+	route  *CallbackRoute
+	params map[string]string
+}
+
+// newCallbackNode 创建空节点
+func newCallbackNode() *callbackNode {
+	return &callbackNode{static: make(map[string]*callbackNode)}
+}
+
+// insert 插入路由，返回在同一节点上作用域相同的已有路由（即匹配完全相同回调数据的冲突路由）。
+// 同一节点上带条件作用域的路由排在普通路由之前，以便优先匹配。
+func (n *callbackNode) insert(route *CallbackRoute) []*CallbackRoute {
+	node := n
+	for _, seg := range strings.Split(route.pattern, "/") {
+		switch {
+		case strings.HasPrefix(seg, ":"):
+			if node.param == nil {
+				node.param = newCallbackNode()
+			}
+			node = node.param
+		case seg == "*":
+			if node.wildcard == nil {
+				node.wildcard = newCallbackNode()
+			}
+			node = node.wildcard
+		default:
+			child, ok := node.static[seg]
+			if !ok {
+				child = newCallbackNode()
+				node.static[seg] = child
+			}
+			node = child
+		}
+	}
+	var conflicts []*CallbackRoute
+	for _, r := range node.routes {
+		if r.scope == route.scope {
+			conflicts = append(conflicts, r)
+		}
+	}
+	pos := len(node.routes)
+	if route.scope.specific() {
+		for i, r := range node.routes {
+			if !r.scope.specific() {
+				pos = i
+				break
+			}
+		}
+	}
+	node.routes = append(node.routes, nil)
+	copy(node.routes[pos+1:], node.routes[pos:])
+	node.routes[pos] = route
+	return conflicts
+}
+
+// lookup 查找匹配 path 且被 accept 接受的路由。
+// all 为 false 时只返回优先级最高的一个路由；为 true 时按优先级返回全部匹配的路由。
+func (n *callbackNode) lookup(path string, all bool, accept func(*CallbackRoute) bool) []callbackMatch {
+	if n == nil {
+		return nil
+	}
+	var matches []callbackMatch
+	seen := make(map[*CallbackRoute]bool)
+	n.walk(strings.Split(path, "/"), nil, func(route *CallbackRoute, values []string) bool {
+		if seen[route] || !accept(route) {
+			return true
+		}
+		seen[route] = true
+		params := make(map[string]string, len(route.params))
+		for i, name := range route.params {
+			if i < len(values) {
+				params[name] = values[i]
+			}
+		}
+		matches = append(matches, callbackMatch{route: route, params: params})
+		return all
+	})
+	return matches
+}
+
+// walk 深度优先按优先级遍历匹配的路由，visit 返回 false 时停止遍历
+func (n *callbackNode) walk(segs []string, values []string, visit func(*CallbackRoute, []string) bool) bool {
+	if len(segs) == 0 {
+		for _, r := range n.routes {
+			if !visit(r, values) {
+				return false
+			}
+		}
+		return true
+	}
+	seg := segs[0]
+	if child, ok := n.static[seg]; ok {
+		if !child.walk(segs[1:], values, visit) {
+			return false
+		}
+	}
+	// 参数段匹配一个非空段
+	if n.param != nil && seg != "" {
+		if !n.param.walk(segs[1:], append(values[:len(values):len(values)], seg), visit) {
+			return false
+		}
+	}
+	// 通配符匹配一个或多个段，优先匹配较短的部分
+	if n.wildcard != nil {
+		for i := 1; i <= len(segs); i++ {
+			if !n.wildcard.walk(segs[i:], values, visit) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// SetCallbackFallThrough 设置回调路由是否贯穿匹配。
+// 默认只执行优先级最高的一个回调路由；开启后按优先级依次执行所有匹配的路由，直到被中断。
+func (t *TelegramRouter) SetCallbackFallThrough(enabled bool) *TelegramRouter {
+	t.mu.Lock()
+	t.callbackFallThrough = enabled
+	t.mu.Unlock()
+	return t
+}
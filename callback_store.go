@@ -0,0 +1,148 @@
+package tgr
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// callbackTokenPrefix 服务端存储的回调数据令牌前缀，回调路由模式不应以此开头
+const callbackTokenPrefix = "~"
+
+// defaultCallbackTTL 回调数据默认保存时长
+const defaultCallbackTTL = 24 * time.Hour
+
+// CallbackStore 超长回调数据的服务端存储。
+// 内置内存实现 MemoryCallbackStore，持久化存储（Redis、数据库等）实现此接口即可。
+type CallbackStore interface {
+	// Set 保存回调数据，ttl 到期后可以丢弃
+	Set(ctx context.Context, token, data string, ttl time.Duration) error
+	// Get 读取回调数据，不存在或已过期时 ok 为 false
+	Get(ctx context.Context, token string) (data string, ok bool, err error)
+}
+
+// SetCallbackStore 开启超长回调数据的服务端存储。
+// 开启后键盘辅助方法（InlineButton、CallbackButton）会把超过 64 字节的回调数据存入 store，
+// 按钮中只携带短令牌；HandleUpdate 在执行任何处理函数（OnUpdate、谓词路由、中间件）前将令牌还原为原始数据，
+// 因此 c.CallbackQuery.Data、c.Param、c.Query 与 c.BindCallback 不受影响。ttl <= 0 时使用默认的 24 小时。
+func (t *TelegramRouter) SetCallbackStore(store CallbackStore, ttl time.Duration) *TelegramRouter {
+	if ttl <= 0 {
+		ttl = defaultCallbackTTL
+	}
+	t.mu.Lock()
+	t.callbackStore = store
+	t.callbackTTL = ttl
+	t.mu.Unlock()
+	return t
+}
+
+// InlineButton 创建回调按钮。
+// 回调数据超过 64 字节时：设置了 CallbackStore 则存入服务端并使用令牌，否则返回 ErrCallbackDataTooLong。
+func (t *TelegramRouter) InlineButton(text, data string) (tgbotapi.InlineKeyboardButton, error) {
+	data, err := t.shortenCallbackData(context.Background(), data)
+	if err != nil {
+		return tgbotapi.InlineKeyboardButton{}, err
+	}
+	return tgbotapi.NewInlineKeyboardButtonData(text, data), nil
+}
+
+// shortenCallbackData 在需要时将回调数据存入服务端，返回可放入按钮的数据
+func (t *TelegramRouter) shortenCallbackData(ctx context.Context, data string) (string, error) {
+	t.mu.RLock()
+	store, ttl := t.callbackStore, t.callbackTTL
+	t.mu.RUnlock()
+	// 以令牌前缀开头的短数据也存入服务端，避免被误当作令牌
+	if len(data) <= MaxCallbackDataLen && (store == nil || !strings.HasPrefix(data, callbackTokenPrefix)) {
+		return data, nil
+	}
+	if store == nil {
+		return "", fmt.Errorf("%w: %q is %d bytes", ErrCallbackDataTooLong, data, len(data))
+	}
+	token, err := newCallbackToken()
+	if err != nil {
+		return "", err
+	}
+	if err := store.Set(ctx, token, data, ttl); err != nil {
+		return "", fmt.Errorf("store callback data: %w", err)
+	}
+	return token, nil
+}
+
+// resolveCallbackData 将令牌还原为原始回调数据，非令牌数据原样返回
+func (t *TelegramRouter) resolveCallbackData(ctx context.Context, data string) (string, error) {
+	if !strings.HasPrefix(data, callbackTokenPrefix) {
+		return data, nil
+	}
+	t.mu.RLock()
+	store := t.callbackStore
+	t.mu.RUnlock()
+	if store == nil {
+		return data, nil
+	}
+	full, ok, err := store.Get(ctx, data)
+	if err != nil {
+		return data, err
+	}
+	if !ok {
+		return data, fmt.Errorf("callback token %q not found or expired", data)
+	}
+	return full, nil
+}
+
+// restoreCallbackData 将回调查询中的令牌替换为原始回调数据，还原失败时记录错误并保留令牌
+func (t *TelegramRouter) restoreCallbackData(c *Context) {
+	q := c.CallbackQuery
+	if q == nil {
+		return
+	}
+	data, err := t.resolveCallbackData(c, q.Data)
+	if err != nil {
+		if t.Logger != nil {
+			t.Logger.Printf("还原回调数据失败: %v", err)
+		}
+		if t.errorReporter != nil {
+			t.errorReporter.Report(c, err, "callback_data", q.Data)
+		}
+	}
+	q.Data = data
+}
+
+// newCallbackToken 生成随机令牌，如 "~3q2-7wZk9aBcD1eF"
+func newCallbackToken() (string, error) {
+	b := make([]byte, 12)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return callbackTokenPrefix + base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// MemoryCallbackStore 基于内存的 CallbackStore，过期数据会被自动清理。
+// 进程重启后数据丢失，多实例部署请使用持久化实现。
+type MemoryCallbackStore struct {
+	items *ttlMap
+}
+
+// NewMemoryCallbackStore 创建内存回调数据存储
+func NewMemoryCallbackStore() *MemoryCallbackStore {
+	return &MemoryCallbackStore{items: newTTLMap()}
+}
+
+// Set 保存回调数据
+func (s *MemoryCallbackStore) Set(_ context.Context, token, data string, ttl time.Duration) error {
+	s.items.set(token, data, ttl)
+	return nil
+}
+
+// Get 读取回调数据
+func (s *MemoryCallbackStore) Get(_ context.Context, token string) (string, bool, error) {
+	v, ok := s.items.get(token)
+	if !ok {
+		return "", false, nil
+	}
+	return v.(string), true, nil
+}
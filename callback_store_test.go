@@ -0,0 +1,142 @@
+package tgr
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// errorLog 记录上报的错误
+type errorLog struct {
+	errs []error
+}
+
+func (l *errorLog) Report(_ context.Context, err error, _ ...any) {
+	l.errs = append(l.errs, err)
+}
+
+func TestInlineButton(t *testing.T) {
+	long := "item/" + strings.Repeat("x", 80)
+	tests := []struct {
+		name      string
+		store     bool
+		data      string
+		wantToken bool // 按钮中应为令牌
+		wantErr   error
+	}{
+		{name: "short data", data: "item/1"},
+		{name: "short data with store", store: true, data: "item/1"},
+		{name: "exactly 64 bytes", data: strings.Repeat("x", MaxCallbackDataLen)},
+		{name: "too long without store", data: long, wantErr: ErrCallbackDataTooLong},
+		{name: "too long with store", store: true, data: long, wantToken: true},
+		{name: "token prefix with store", store: true, data: "~item", wantToken: true},
+		{name: "token prefix without store", data: "~item"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := NewTelegramRouter(nil)
+			if tt.store {
+				router.SetCallbackStore(NewMemoryCallbackStore(), 0)
+			}
+			button, err := router.InlineButton("go", tt.data)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("err %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := *button.CallbackData
+			if len(got) > MaxCallbackDataLen {
+				t.Errorf("callback data is %d bytes", len(got))
+			}
+			if isToken := got != tt.data && strings.HasPrefix(got, callbackTokenPrefix); isToken != tt.wantToken {
+				t.Errorf("callback data %q, want token %v", got, tt.wantToken)
+			}
+		})
+	}
+}
+
+func TestCallbackTokenRoundTrip(t *testing.T) {
+	router := NewTelegramRouter(nil).SetCallbackStore(NewMemoryCallbackStore(), time.Hour)
+	id := strings.Repeat("9", 80)
+	data := "item/" + id + "?page=2"
+	button, err := router.InlineButton("go", data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 中间件、OnUpdate 与谓词都应看到原始数据
+	var seen []string
+	record := func(c *Context) { seen = append(seen, c.CallbackQuery.Data) }
+	router.Use(func(c *Context) {
+		record(c)
+		c.Next()
+	})
+	router.OnUpdate(record)
+	router.WithDispatchPolicy(AllMatch).On(func(u *tgbotapi.Update) bool {
+		return u.CallbackQuery != nil && u.CallbackQuery.Data == data
+	}, record)
+	var param, page string
+	router.Callback("item/:id", func(c *Context) {
+		param, page = c.Param("id"), c.Query("page")
+	})
+
+	router.HandleUpdate(callbackUpdate(*button.CallbackData))
+	if param != id || page != "2" {
+		t.Errorf("param %q, page %q", param, page)
+	}
+	// 中间件在 OnUpdate、谓词路由与回调路由之前各执行一次
+	if len(seen) != 5 {
+		t.Fatalf("seen %d callbacks, want 5: %q", len(seen), seen)
+	}
+	for _, s := range seen {
+		if s != data {
+			t.Errorf("handler saw %q, want original data", s)
+		}
+	}
+}
+
+func TestCallbackTokenUnresolved(t *testing.T) {
+	store := NewMemoryCallbackStore()
+	expired, err := NewTelegramRouter(nil).SetCallbackStore(store, time.Nanosecond).InlineButton("go", "item/"+strings.Repeat("x", 80))
+	if err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(time.Millisecond)
+
+	tests := []struct {
+		name string
+		data string
+	}{
+		{name: "expired", data: *expired.CallbackData},
+		{name: "missing", data: "~missing"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reports := &errorLog{}
+			router := NewTelegramRouter(nil).SetCallbackStore(store, time.Hour).SetErrorReporter(reports)
+			rec := &recorder{}
+			router.Callback("item/:id", rec.handler("item"))
+			router.NoCallback(func(c *Context) {
+				// 还原失败时保留令牌
+				if c.CallbackQuery.Data == tt.data {
+					rec.log = append(rec.log, "nocallback")
+				}
+			})
+			router.HandleUpdate(callbackUpdate(tt.data))
+			if len(rec.log) != 1 || rec.log[0] != "nocallback" {
+				t.Errorf("ran %v, want [nocallback]", rec.log)
+			}
+			if len(reports.errs) != 1 {
+				t.Errorf("reported %v, want one error", reports.errs)
+			}
+		})
+	}
+}
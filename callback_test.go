@@ -0,0 +1,109 @@
+package tgr
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCallbackRoutes(t *testing.T) {
+	tests := []struct {
+		name        string
+		patterns    []string
+		data        string
+		fallThrough bool
+		want        []string          // 按执行顺序排列的路由模式
+		params      map[string]string // 第一个执行的路由收到的参数
+	}{
+		{
+			name:     "static before param before wildcard",
+			patterns: []string{"item/*", "item/:id", "item/new"},
+			data:     "item/new",
+			want:     []string{"item/new"},
+			params:   map[string]string{},
+		},
+		{
+			name:        "fall through in priority order",
+			patterns:    []string{"item/*", "item/:id", "item/new"},
+			data:        "item/new",
+			fallThrough: true,
+			want:        []string{"item/new", "item/:id", "item/*"},
+			params:      map[string]string{},
+		},
+		{
+			name:     "param captures one segment",
+			patterns: []string{"item/:id/edit"},
+			data:     "item/42/edit",
+			want:     []string{"item/:id/edit"},
+			params:   map[string]string{"id": "42"},
+		},
+		{
+			name:     "backtrack from static to param",
+			patterns: []string{"menu/main/open", "menu/:name/close"},
+			data:     "menu/main/close",
+			want:     []string{"menu/:name/close"},
+			params:   map[string]string{"name": "main"},
+		},
+		{
+			name:     "backtrack from param to wildcard",
+			patterns: []string{"menu/:name/open", "menu/*"},
+			data:     "menu/main/close",
+			want:     []string{"menu/*"},
+			params:   map[string]string{},
+		},
+		{
+			name:     "wildcard matches several segments",
+			patterns: []string{"files/*/end"},
+			data:     "files/a/b/end",
+			want:     []string{"files/*/end"},
+			params:   map[string]string{},
+		},
+		{
+			name:     "wildcard needs at least one segment",
+			patterns: []string{"files/*"},
+			data:     "files",
+		},
+		{
+			name:     "param does not match an empty segment",
+			patterns: []string{"item/:id"},
+			data:     "item/",
+		},
+		{
+			name:     "query is not part of the path",
+			patterns: []string{"order/:id"},
+			data:     "order/7?status=paid",
+			want:     []string{"order/:id"},
+			params:   map[string]string{"id": "7"},
+		},
+		{
+			name:        "same pattern in registration order",
+			patterns:    []string{"a/:x", "a/:y"},
+			data:        "a/1",
+			fallThrough: true,
+			want:        []string{"a/:x", "a/:y"},
+			params:      map[string]string{"x": "1"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := NewTelegramRouter(nil).SetCallbackFallThrough(tt.fallThrough)
+			var got []string
+			var params map[string]string
+			for _, pattern := range tt.patterns {
+				pattern := pattern
+				router.Callback(pattern, func(c *Context) {
+					if got == nil {
+						params = c.params
+					}
+					got = append(got, pattern)
+				})
+			}
+			router.HandleUpdate(callbackUpdate(tt.data))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ran %v, want %v", got, tt.want)
+			}
+			if !reflect.DeepEqual(params, tt.params) {
+				t.Errorf("params %v, want %v", params, tt.params)
+			}
+		})
+	}
+}
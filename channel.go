@@ -0,0 +1,38 @@
+package tgr
+
+// Channel 返回频道消息路由分组。
+// 分组内注册的 Command、Text、TextRegex、Photo、Document 等路由只处理频道消息（ChannelPost），
+// 其他分组的路由不会收到频道消息。频道消息在 ChannelPost 处理函数之后按普通消息分发，
+// c.Message 指向频道消息，c.Reply 等方法会发送到该频道。
+//
+// 示例:
+//
+//	channel := router.Channel()
+//	channel.Command("pin", pinHandler)
+//	channel.Photo(func(c *Context) {
+//	    c.Reply("收到频道图片").Send()
+//	})
+func (g *RouterGroup) Channel() *RouterGroup {
+	return &RouterGroup{
+		router:  g.router,
+		parent:  g,
+		channel: true,
+	}
+}
+
+// IsChannelPost 当前消息是否为频道消息
+func (c *Context) IsChannelPost() bool {
+	return c.channel
+}
+
+// routeChannelPost 将 c.Update 替换为以频道消息作为 Message 的副本，之后只有频道分组的路由匹配
+func (t *TelegramRouter) routeChannelPost(c *Context) {
+	update := c.Update
+	if update.ChannelPost == nil || update.Message != nil {
+		return
+	}
+	post := *update
+	post.Message = update.ChannelPost
+	c.Update = &post
+	c.channel = true
+}
@@ -0,0 +1,98 @@
+package tgr
+
+import "fmt"
+
+// chatTypeMask 路由作用域限定的聊天类型，0 表示不限定
+type chatTypeMask uint8
+
+const (
+	chatPrivate chatTypeMask = 1 << iota
+	chatGroup
+	chatSupergroup
+	chatChannel
+
+	// chatTypeSet 标记已限定聊天类型，保证嵌套分组取交集后不会退化为“不限定”
+	chatTypeSet chatTypeMask = 1 << 7
+)
+
+// chatTypeBits 聊天类型名称与掩码位
+var chatTypeBits = []struct {
+	name string
+	bit  chatTypeMask
+}{
+	{"private", chatPrivate},
+	{"group", chatGroup},
+	{"supergroup", chatSupergroup},
+	{"channel", chatChannel},
+}
+
+// has 判断掩码是否包含指定的聊天类型
+func (m chatTypeMask) has(chatType string) bool {
+	for _, b := range chatTypeBits {
+		if b.name == chatType {
+			return m&b.bit != 0
+		}
+	}
+	return false
+}
+
+// names 返回掩码包含的聊天类型名称
+func (m chatTypeMask) names() []string {
+	var names []string
+	for _, b := range chatTypeBits {
+		if m&b.bit != 0 {
+			names = append(names, b.name)
+		}
+	}
+	return names
+}
+
+// ChatTypes 返回只处理指定聊天类型（"private"、"group"、"supergroup"、"channel"）更新的路由分组。
+// 嵌套时取交集。限定聊天类型的路由与会话状态路由一样优先于普通路由，
+// 因此同一个命令可以在私聊和群组中分别注册不同的处理函数，并保留一个普通路由作为其他聊天的兜底。
+// 无法确定聊天的更新（如内联查询）不会匹配限定聊天类型的路由。
+//
+// 示例:
+//
+//	router.ChatTypes("supergroup").Command("settings", settingsHandler)
+func (g *RouterGroup) ChatTypes(types ...string) *RouterGroup {
+	mask := chatTypeSet
+	for _, t := range types {
+		found := false
+		for _, b := range chatTypeBits {
+			if b.name == t {
+				mask |= b.bit
+				found = true
+			}
+		}
+		if !found {
+			panic(fmt.Sprintf("tgr: unknown chat type %q", t))
+		}
+	}
+	return &RouterGroup{
+		router:    g.router,
+		parent:    g,
+		chatTypes: mask,
+	}
+}
+
+// Private 返回只处理私聊更新的路由分组
+//
+// 示例:
+//
+//	router.Private().Command("start", dmStartHandler)
+//	router.Groups().Command("start", groupStartHandler)
+func (g *RouterGroup) Private() *RouterGroup {
+	return g.ChatTypes("private")
+}
+
+// Groups 返回只处理群组与超级群组更新的路由分组
+func (g *RouterGroup) Groups() *RouterGroup {
+	return g.ChatTypes("group", "supergroup")
+}
+
+// Channels 返回只处理频道更新的路由分组。
+// 与 Channel 不同，分组内的路由既处理频道消息，也处理频道中的其他更新（如频道消息按钮的回调）。
+func (g *RouterGroup) Channels() *RouterGroup {
+	return g.ChatTypes("channel")
+}
@@ -0,0 +1,270 @@
+package tgr
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// MaxCallbackDataLen Telegram 限制 callback_data 最长 64 字节
+const MaxCallbackDataLen = 64
+
+// ErrCallbackDataTooLong 回调数据超过 Telegram 的 64 字节限制
+var ErrCallbackDataTooLong = errors.New("callback data exceeds 64 bytes")
+
+// callbackField 结构体中参与编解码的字段
+type callbackField struct {
+	name      string
+	index     int
+	omitEmpty bool
+}
+
+// callbackFields 解析结构体的 callback 标签，如 `callback:"id"`、`callback:"page,omitempty"`。
+// 没有标签或标签为 "-" 的字段不参与编解码。
+func callbackFields(typ reflect.Type) []callbackField {
+	fields := make([]callbackField, 0, typ.NumField())
+	for i := 0; i < typ.NumField(); i++ {
+		sf := typ.Field(i)
+		tag, ok := sf.Tag.Lookup("callback")
+		if !ok || tag == "-" || !sf.IsExported() {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		if name == "" {
+			name = sf.Name
+		}
+		fields = append(fields, callbackField{name: name, index: i, omitEmpty: opts == "omitempty"})
+	}
+	return fields
+}
+
+// structValue 解引用指针并检查是否为结构体
+func structValue(v any) (reflect.Value, error) {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			return reflect.Value{}, fmt.Errorf("callback value is nil")
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return reflect.Value{}, fmt.Errorf("callback value must be a struct, got %s", rv.Kind())
+	}
+	return rv, nil
+}
+
+// formatCallbackValue 将字段值格式化为字符串
+func formatCallbackValue(v reflect.Value) (string, error) {
+	switch v.Kind() {
+	case reflect.String:
+		return v.String(), nil
+	case reflect.Bool:
+		if v.Bool() {
+			return "1", nil
+		}
+		return "0", nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10), nil
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'g', -1, v.Type().Bits()), nil
+	}
+	return "", fmt.Errorf("unsupported callback field type %s", v.Type())
+}
+
+// parseCallbackValue 将字符串解析到字段
+func parseCallbackValue(v reflect.Value, s string) error {
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(s, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	default:
+		return fmt.Errorf("unsupported callback field type %s", v.Type())
+	}
+	return nil
+}
+
+// EncodeCallback 按回调路由模式将结构体编码为回调数据。
+// 模式中的参数段（:name）由同名 callback 标签的字段填充，其余带标签的字段编码为查询参数。
+// 编码结果超过 64 字节时返回 ErrCallbackDataTooLong。
+//
+// 示例:
+//
+//	type OrderStatus struct {
+//	    ID     int64  `callback:"id"`
+//	    Status string `callback:"status,omitempty"`
+//	}
+//	data, err := tgr.EncodeCallback("order/:id/status", OrderStatus{ID: 42, Status: "paid"})
+//	// data == "order/42/status?status=paid"
+func EncodeCallback(pattern string, v any) (string, error) {
+	data, err := encodeCallback(pattern, v)
+	if err != nil {
+		return "", err
+	}
+	if len(data) > MaxCallbackDataLen {
+		return "", fmt.Errorf("%w: %q is %d bytes", ErrCallbackDataTooLong, data, len(data))
+	}
+	return data, nil
+}
+
+// encodeCallback 编码回调数据，不检查长度限制
+func encodeCallback(pattern string, v any) (string, error) {
+	rv, err := structValue(v)
+	if err != nil {
+		return "", err
+	}
+	fields := callbackFields(rv.Type())
+	byName := make(map[string]callbackField, len(fields))
+	for _, f := range fields {
+		byName[f.name] = f
+	}
+
+	used := make(map[string]bool)
+	segs := strings.Split(pattern, "/")
+	for i, seg := range segs {
+		switch {
+		case strings.HasPrefix(seg, ":"):
+			name := seg[1:]
+			f, ok := byName[name]
+			if !ok {
+				return "", fmt.Errorf("callback pattern %q: no field for param %q", pattern, name)
+			}
+			s, err := formatCallbackValue(rv.Field(f.index))
+			if err != nil {
+				return "", fmt.Errorf("callback field %q: %w", name, err)
+			}
+			if s == "" {
+				return "", fmt.Errorf("callback pattern %q: param %q is empty", pattern, name)
+			}
+			segs[i] = url.PathEscape(s)
+			used[name] = true
+		case seg == "*":
+			return "", fmt.Errorf("callback pattern %q: wildcard segments cannot be encoded", pattern)
+		}
+	}
+
+	data := strings.Join(segs, "/")
+	var query []string
+	for _, f := range fields {
+		if used[f.name] {
+			continue
+		}
+		fv := rv.Field(f.index)
+		if f.omitEmpty && fv.IsZero() {
+			continue
+		}
+		s, err := formatCallbackValue(fv)
+		if err != nil {
+			return "", fmt.Errorf("callback field %q: %w", f.name, err)
+		}
+		query = append(query, url.QueryEscape(f.name)+"="+url.QueryEscape(s))
+	}
+	if len(query) > 0 {
+		data += "?" + strings.Join(query, "&")
+	}
+	return data, nil
+}
+
+// NewCallbackButton 按回调路由模式编码结构体并创建内联按钮，回调数据超过 64 字节时返回错误
+func NewCallbackButton(text, pattern string, v any) (tgbotapi.InlineKeyboardButton, error) {
+	data, err := EncodeCallback(pattern, v)
+	if err != nil {
+		return tgbotapi.InlineKeyboardButton{}, err
+	}
+	return tgbotapi.NewInlineKeyboardButtonData(text, data), nil
+}
+
+// CallbackButton 为已注册的回调路由创建内联按钮。
+// 与 NewCallbackButton 相同，但会检查 pattern 是否已通过 Callback 注册；
+// 设置了 CallbackStore 时，超过 64 字节的回调数据会保存在服务端，按钮中只携带短令牌。
+func (t *TelegramRouter) CallbackButton(text, pattern string, v any) (tgbotapi.InlineKeyboardButton, error) {
+	if !t.hasCallbackPattern(pattern) {
+		return tgbotapi.InlineKeyboardButton{}, fmt.Errorf("callback pattern %q is not registered", pattern)
+	}
+	data, err := encodeCallback(pattern, v)
+	if err != nil {
+		return tgbotapi.InlineKeyboardButton{}, err
+	}
+	return t.InlineButton(text, data)
+}
+
+// hasCallbackPattern 检查回调路由模式是否已注册
+func (t *TelegramRouter) hasCallbackPattern(pattern string) bool {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	for _, r := range t.callbackRoutes {
+		if r.pattern == pattern {
+			return true
+		}
+	}
+	return false
+}
+
+// BindCallback 将当前回调的路由参数与查询参数解码到结构体指针 v。
+// 字段通过 callback 标签映射，类型不匹配时返回错误；回调数据中没有的字段保持原值。
+//
+// 示例:
+//
+//	router.Callback("order/:id/status", func(c *Context) {
+//	    var req OrderStatus
+//	    if err := c.BindCallback(&req); err != nil {
+//	        _ = c.AnswerCallback(tgr.AnswerCallbackOptions{Text: "无效的请求"})
+//	        return
+//	    }
+//	})
+func (c *Context) BindCallback(v any) error {
+	if c.CallbackQuery == nil {
+		return fmt.Errorf("no callback query to bind")
+	}
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() {
+		return fmt.Errorf("BindCallback requires a non-nil struct pointer")
+	}
+	sv, err := structValue(v)
+	if err != nil {
+		return err
+	}
+	for _, f := range callbackFields(sv.Type()) {
+		raw, ok := c.params[f.name]
+		if ok {
+			if raw, err = url.PathUnescape(raw); err != nil {
+				return fmt.Errorf("callback field %q: %w", f.name, err)
+			}
+		} else if raw, ok = c.query[f.name]; !ok {
+			continue
+		}
+		if err := parseCallbackValue(sv.Field(f.index), raw); err != nil {
+			return fmt.Errorf("callback field %q: %w", f.name, err)
+		}
+	}
+	return nil
+}
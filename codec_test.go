@@ -0,0 +1,154 @@
+package tgr
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+type codecOrder struct {
+	ID     int64   `callback:"id"`
+	Status string  `callback:"status,omitempty"`
+	Page   uint8   `callback:"page,omitempty"`
+	Paid   bool    `callback:"paid"`
+	Price  float64 `callback:"price,omitempty"`
+	Note   string  // 没有标签，不参与编解码
+}
+
+type codecName struct {
+	Name string `callback:"name"`
+	Tag  string `callback:"tag,omitempty"`
+}
+
+func TestEncodeCallback(t *testing.T) {
+	tests := []struct {
+		name    string
+		pattern string
+		value   any
+		want    string
+		wantErr error // 非 nil 时要求 errors.Is 匹配
+		fail    bool
+	}{
+		{name: "params and query", pattern: "order/:id/status", value: codecOrder{ID: 42, Status: "paid", Note: "x"}, want: "order/42/status?status=paid&paid=0"},
+		{name: "pointer", pattern: "order/:id", value: &codecOrder{ID: 1, Paid: true, Price: 1.5}, want: "order/1?paid=1&price=1.5"},
+		{name: "escaped param", pattern: "user/:name", value: codecName{Name: "a b/c", Tag: "x&y"}, want: "user/a%20b%2Fc?tag=x%26y"},
+		{name: "missing field for param", pattern: "user/:uid", value: codecName{Name: "a"}, fail: true},
+		{name: "empty param", pattern: "user/:name", value: codecName{}, fail: true},
+		{name: "wildcard", pattern: "user/*", value: codecName{Name: "a"}, fail: true},
+		{name: "not a struct", pattern: "n/:id", value: 42, fail: true},
+		{name: "nil pointer", pattern: "n/:id", value: (*codecOrder)(nil), fail: true},
+		{name: "too long", pattern: "user/:name", value: codecName{Name: strings.Repeat("a", 64)}, wantErr: ErrCallbackDataTooLong},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := EncodeCallback(tt.pattern, tt.value)
+			switch {
+			case tt.wantErr != nil:
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("err %v, want %v", err, tt.wantErr)
+				}
+			case tt.fail:
+				if err == nil {
+					t.Fatalf("got %q, want error", got)
+				}
+			case err != nil:
+				t.Fatal(err)
+			case got != tt.want:
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCallbackRoundTrip(t *testing.T) {
+	tests := []struct {
+		name    string
+		pattern string
+		value   any
+		target  func() any // 返回用于解码的指针
+	}{
+		{
+			name:    "all field kinds",
+			pattern: "order/:id/status",
+			value:   &codecOrder{ID: -42, Status: "paid out", Page: 3, Paid: true, Price: 9.75},
+			target:  func() any { return &codecOrder{} },
+		},
+		{
+			name:    "escaped param and query",
+			pattern: "user/:name",
+			value:   &codecName{Name: "a b/c?d", Tag: "x&y=z"},
+			target:  func() any { return &codecName{} },
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := EncodeCallback(tt.pattern, tt.value)
+			if err != nil {
+				t.Fatal(err)
+			}
+			router := NewTelegramRouter(nil)
+			got := tt.target()
+			var bindErr error
+			ran := false
+			router.Callback(tt.pattern, func(c *Context) {
+				ran = true
+				bindErr = c.BindCallback(got)
+			})
+			router.HandleUpdate(callbackUpdate(data))
+			if !ran {
+				t.Fatalf("route %q did not match %q", tt.pattern, data)
+			}
+			if bindErr != nil {
+				t.Fatal(bindErr)
+			}
+			if !reflect.DeepEqual(got, tt.value) {
+				t.Errorf("decoded %+v, want %+v", got, tt.value)
+			}
+		})
+	}
+}
+
+func TestBindCallbackErrors(t *testing.T) {
+	callback := &tgbotapi.Update{CallbackQuery: &tgbotapi.CallbackQuery{Data: "x"}}
+	tests := []struct {
+		name   string
+		update *tgbotapi.Update
+		params map[string]string
+		query  map[string]string
+		target any
+	}{
+		{name: "not a callback", update: &tgbotapi.Update{}, target: &codecOrder{}},
+		{name: "not a pointer", update: callback, target: codecOrder{}},
+		{name: "nil pointer", update: callback, target: (*codecOrder)(nil)},
+		{name: "pointer to non-struct", update: callback, target: new(int)},
+		{name: "int param", update: callback, params: map[string]string{"id": "abc"}, target: &codecOrder{}},
+		{name: "bool query", update: callback, query: map[string]string{"paid": "maybe"}, target: &codecOrder{}},
+		{name: "uint overflow", update: callback, query: map[string]string{"page": "300"}, target: &codecOrder{}},
+		{name: "bad escape", update: callback, params: map[string]string{"name": "%zz"}, target: &codecName{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &Context{Update: tt.update, params: tt.params, query: tt.query}
+			if err := c.BindCallback(tt.target); err == nil {
+				t.Error("BindCallback succeeded, want error")
+			}
+		})
+	}
+}
+
+func TestBindCallbackKeepsMissingFields(t *testing.T) {
+	c := &Context{
+		Update: &tgbotapi.Update{CallbackQuery: &tgbotapi.CallbackQuery{Data: "x"}},
+		params: map[string]string{"id": "5"},
+	}
+	got := codecOrder{Status: "kept", Note: "kept"}
+	if err := c.BindCallback(&got); err != nil {
+		t.Fatal(err)
+	}
+	if want := (codecOrder{ID: 5, Status: "kept", Note: "kept"}); got != want {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,248 @@
+package tgr
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// commandArg 命令参数声明，如 ":user_id:int"、":duration?:duration"、":reason..."
+type commandArg struct {
+	name     string
+	typ      string // string、int、float、bool、duration
+	optional bool
+	rest     bool // 剩余参数，匹配其后的全部文本
+}
+
+// commandArgs 命令参数列表
+type commandArgs struct {
+	command string
+	args    []commandArg
+}
+
+// commandArgTypes 支持的参数类型及校验函数
+var commandArgTypes = map[string]func(string) error{
+	"string": func(string) error { return nil },
+	"int": func(s string) error {
+		_, err := strconv.ParseInt(s, 10, 64)
+		return err
+	},
+	"float": func(s string) error {
+		_, err := strconv.ParseFloat(s, 64)
+		return err
+	},
+	"bool": func(s string) error {
+		_, err := strconv.ParseBool(s)
+		return err
+	},
+	"duration": func(s string) error {
+		_, err := time.ParseDuration(s)
+		return err
+	},
+}
+
+// parseCommandPattern 解析命令模式，返回命令名与参数声明（没有参数时为 nil）。
+// 模式格式："<command> :name[?][:type][...] ..."，类型缺省为 string。
+// 模式非法时 panic，与注册时的其他编程错误一致尽早暴露。
+func parseCommandPattern(pattern string) (string, *commandArgs) {
+	fields := strings.Fields(pattern)
+	if len(fields) == 0 {
+		return "", nil
+	}
+	command := strings.TrimPrefix(fields[0], "/")
+	if len(fields) == 1 {
+		return command, nil
+	}
+	spec := &commandArgs{command: command}
+	for i, f := range fields[1:] {
+		if !strings.HasPrefix(f, ":") || len(f) == 1 {
+			panic(fmt.Sprintf("tgr: command %q: invalid argument %q", pattern, f))
+		}
+		var arg commandArg
+		f = f[1:]
+		if strings.HasSuffix(f, "...") {
+			arg.rest = true
+			f = strings.TrimSuffix(f, "...")
+		}
+		name, typ, _ := strings.Cut(f, ":")
+		if strings.HasSuffix(name, "?") {
+			arg.optional = true
+			name = strings.TrimSuffix(name, "?")
+		}
+		if typ == "" {
+			typ = "string"
+		}
+		if _, ok := commandArgTypes[typ]; !ok {
+			panic(fmt.Sprintf("tgr: command %q: unknown type %q for argument %q", pattern, typ, name))
+		}
+		if name == "" {
+			panic(fmt.Sprintf("tgr: command %q: empty argument name", pattern))
+		}
+		if arg.rest && i != len(fields)-2 {
+			panic(fmt.Sprintf("tgr: command %q: rest argument %q must be the last one", pattern, name))
+		}
+		if !arg.optional && len(spec.args) > 0 && spec.args[len(spec.args)-1].optional {
+			panic(fmt.Sprintf("tgr: command %q: required argument %q follows an optional one", pattern, name))
+		}
+		arg.name, arg.typ = name, typ
+		spec.args = append(spec.args, arg)
+	}
+	return command, spec
+}
+
+// Usage 返回用法说明，如 "/ban <user_id:int> [duration:duration]"
+func (s *commandArgs) Usage() string {
+	var b strings.Builder
+	b.WriteString("/" + s.command)
+	for _, a := range s.args {
+		label := a.name
+		if a.typ != "string" {
+			label += ":" + a.typ
+		}
+		if a.rest {
+			label += "..."
+		}
+		if a.optional {
+			b.WriteString(" [" + label + "]")
+		} else {
+			b.WriteString(" <" + label + ">")
+		}
+	}
+	return b.String()
+}
+
+// commandToken 命令参数中的一个词及其在原文中的起始位置
+type commandToken struct {
+	value string
+	start int
+}
+
+// splitCommandArgs 按空白切分参数，支持双引号或单引号包裹含空格的参数，引号内可用 \ 转义
+func splitCommandArgs(s string) ([]commandToken, error) {
+	var tokens []commandToken
+	runes := []rune(s)
+	offset := 0 // 当前 rune 对应的字节位置
+	for i := 0; i < len(runes); {
+		if unicode.IsSpace(runes[i]) {
+			offset += len(string(runes[i]))
+			i++
+			continue
+		}
+		start := offset
+		var b strings.Builder
+		if q := runes[i]; q == '"' || q == '\'' {
+			offset += len(string(q))
+			i++
+			closed := false
+			for i < len(runes) {
+				r := runes[i]
+				offset += len(string(r))
+				i++
+				if r == '\\' && i < len(runes) {
+					b.WriteRune(runes[i])
+					offset += len(string(runes[i]))
+					i++
+					continue
+				}
+				if r == q {
+					closed = true
+					break
+				}
+				b.WriteRune(r)
+			}
+			if !closed {
+				return nil, fmt.Errorf("unterminated quote")
+			}
+		} else {
+			for i < len(runes) && !unicode.IsSpace(runes[i]) {
+				b.WriteRune(runes[i])
+				offset += len(string(runes[i]))
+				i++
+			}
+		}
+		tokens = append(tokens, commandToken{value: b.String(), start: start})
+	}
+	return tokens, nil
+}
+
+// parse 解析并校验参数文本，返回参数名到参数值的映射
+func (s *commandArgs) parse(text string) (map[string]string, error) {
+	tokens, err := splitCommandArgs(text)
+	if err != nil {
+		return nil, err
+	}
+	values := make(map[string]string, len(s.args))
+	for i, a := range s.args {
+		if i >= len(tokens) {
+			if a.optional {
+				break
+			}
+			return nil, fmt.Errorf("missing argument %q", a.name)
+		}
+		value := tokens[i].value
+		if a.rest {
+			// 剩余参数保留原文；只有一个带引号的词时去掉引号
+			value = strings.TrimSpace(text[tokens[i].start:])
+			if i == len(tokens)-1 {
+				value = tokens[i].value
+			}
+		}
+		if err := commandArgTypes[a.typ](value); err != nil {
+			return nil, fmt.Errorf("invalid %s %q: expected %s", a.name, value, a.typ)
+		}
+		values[a.name] = value
+	}
+	if n := len(s.args); n > 0 && !s.args[n-1].rest && len(tokens) > n {
+		return nil, fmt.Errorf("too many arguments")
+	}
+	return values, nil
+}
+
+// bind 解析命令参数并写入路由参数；失败时调用用法处理函数并中断处理链
+func (s *commandArgs) bind(c *Context) {
+	if c.Message == nil {
+		return
+	}
+	values, err := s.parse(c.Message.CommandArguments())
+	if err != nil {
+		handler := defaultCommandUsageHandler
+		if c.router != nil {
+			c.router.mu.RLock()
+			if c.router.commandUsageHandler != nil {
+				handler = c.router.commandUsageHandler
+			}
+			c.router.mu.RUnlock()
+		}
+		handler(c, s.Usage(), err)
+		c.Abort()
+		return
+	}
+	if c.params == nil {
+		c.params = make(map[string]string, len(values))
+	}
+	for k, v := range values {
+		c.params[k] = v
+	}
+}
+
+// CommandUsageHandler 命令参数解析失败时的处理函数，usage 为根据模式生成的用法说明
+type CommandUsageHandler func(c *Context, usage string, err error)
+
+// defaultCommandUsageHandler 默认回复错误原因与用法说明
+func defaultCommandUsageHandler(c *Context, usage string, err error) {
+	if b := c.Reply(err.Error() + "\nUsage: " + usage); b != nil {
+		if _, sendErr := b.Send(); sendErr != nil && c.Logger != nil {
+			c.Logger.Printf("发送命令用法失败: %v", sendErr)
+		}
+	}
+}
+
+// SetCommandUsageHandler 设置命令参数解析失败时的处理函数，默认回复错误原因与用法说明
+func (t *TelegramRouter) SetCommandUsageHandler(handler CommandUsageHandler) *TelegramRouter {
+	t.mu.Lock()
+	t.commandUsageHandler = handler
+	t.mu.Unlock()
+	return t
+}
@@ -0,0 +1,123 @@
+package tgr
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCommandArgsParse(t *testing.T) {
+	const ban = "ban :user_id:int :duration?:duration :reason?..."
+	const say = "say :text"
+	tests := []struct {
+		name    string
+		pattern string
+		args    string
+		want    map[string]string
+		wantErr string
+	}{
+		{name: "required only", pattern: ban, args: "42", want: map[string]string{"user_id": "42"}},
+		{name: "optional", pattern: ban, args: "42 1h", want: map[string]string{"user_id": "42", "duration": "1h"}},
+		{name: "rest keeps text", pattern: ban, args: "42 1h  spamming   a lot ", want: map[string]string{"user_id": "42", "duration": "1h", "reason": "spamming   a lot"}},
+		{name: "rest quoted token", pattern: ban, args: `42 1h "spam, links"`, want: map[string]string{"user_id": "42", "duration": "1h", "reason": "spam, links"}},
+		{name: "rest keeps quotes of several tokens", pattern: ban, args: `42 1h "spam" again`, want: map[string]string{"user_id": "42", "duration": "1h", "reason": `"spam" again`}},
+		{name: "missing required", pattern: ban, args: "", wantErr: `missing argument "user_id"`},
+		{name: "invalid int", pattern: ban, args: "abc", wantErr: `invalid user_id "abc": expected int`},
+		{name: "invalid duration", pattern: ban, args: "42 soon", wantErr: `invalid duration "soon": expected duration`},
+		{name: "double quotes", pattern: say, args: `"hello world"`, want: map[string]string{"text": "hello world"}},
+		{name: "single quotes with escape", pattern: say, args: `'it\'s'`, want: map[string]string{"text": "it's"}},
+		{name: "unicode", pattern: say, args: ` "你好 世界" `, want: map[string]string{"text": "你好 世界"}},
+		{name: "unterminated quote", pattern: say, args: `"hello`, wantErr: "unterminated quote"},
+		{name: "too many arguments", pattern: say, args: "hello world", wantErr: "too many arguments"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, spec := parseCommandPattern(tt.pattern)
+			got, err := spec.parse(tt.args)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("err %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseCommandPattern(t *testing.T) {
+	tests := []struct {
+		pattern   string
+		command   string
+		usage     string // 为空表示没有参数
+		wantPanic bool
+	}{
+		{pattern: "start", command: "start"},
+		{pattern: "/ban :user_id:int :duration?:duration :reason?...", command: "ban", usage: "/ban <user_id:int> [duration:duration] [reason...]"},
+		{pattern: "note :text...", command: "note", usage: "/note <text...>"},
+		{pattern: "ban :user_id?:int :reason", wantPanic: true},
+		{pattern: "ban :user_id:uuid", wantPanic: true},
+		{pattern: "ban :reason... :user_id", wantPanic: true},
+		{pattern: "ban user_id", wantPanic: true},
+		{pattern: "ban ::int", wantPanic: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.pattern, func(t *testing.T) {
+			defer func() {
+				if r := recover(); (r != nil) != tt.wantPanic {
+					t.Fatalf("panic %v, want panic %v", r, tt.wantPanic)
+				}
+			}()
+			command, spec := parseCommandPattern(tt.pattern)
+			if command != tt.command {
+				t.Errorf("command %q, want %q", command, tt.command)
+			}
+			switch {
+			case tt.usage == "" && spec != nil:
+				t.Errorf("got args %+v, want none", spec.args)
+			case tt.usage != "" && (spec == nil || spec.Usage() != tt.usage):
+				t.Errorf("usage of %+v, want %q", spec, tt.usage)
+			}
+		})
+	}
+}
+
+func TestCommandArgsUsageReply(t *testing.T) {
+	tests := []struct {
+		name      string
+		text      string
+		wantParam string // 处理函数收到的 user_id，为空表示不应执行
+		wantErr   string // 用法处理函数收到的错误，为空表示不应调用
+	}{
+		{name: "valid", text: "/ban 42", wantParam: "42"},
+		{name: "with bot name", text: "/ban@test_bot 42 1h", wantParam: "42"},
+		{name: "invalid", text: "/ban abc", wantErr: `invalid user_id "abc": expected int`},
+		{name: "missing", text: "/ban", wantErr: `missing argument "user_id"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := NewTelegramRouter(nil)
+			var gotUsage, gotErr, gotParam string
+			router.SetCommandUsageHandler(func(c *Context, usage string, err error) {
+				gotUsage, gotErr = usage, err.Error()
+			})
+			router.Command("ban :user_id:int :duration?:duration", func(c *Context) {
+				gotParam = c.Param("user_id")
+			})
+			router.HandleUpdate(commandUpdate(tt.text))
+			if gotParam != tt.wantParam {
+				t.Errorf("param %q, want %q", gotParam, tt.wantParam)
+			}
+			if gotErr != tt.wantErr {
+				t.Errorf("usage error %q, want %q", gotErr, tt.wantErr)
+			}
+			if tt.wantErr != "" && gotUsage != "/ban <user_id:int> [duration:duration]" {
+				t.Errorf("usage %q", gotUsage)
+			}
+		})
+	}
+}
@@ -0,0 +1,151 @@
+package tgr
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// MaxStartPayloadLen Telegram 对 /start 深度链接参数的长度限制
+const MaxStartPayloadLen = 64
+
+// startPayloadChars 深度链接参数允许的字符
+var startPayloadChars = regexp.MustCompile(`^[A-Za-z0-9_-]*$`)
+
+// StartPayloadRoute /start 深度链接参数路由
+type StartPayloadRoute struct {
+	*route
+	regex    *regexp.Regexp
+	params   []string
+	composed []composedHandler
+}
+
+// compileStartPattern 将 "ref-:code" 形式的模式编译为正则，参数名由字母、数字组成
+func compileStartPattern(pattern string) (*regexp.Regexp, []string) {
+	var b strings.Builder
+	var params []string
+	b.WriteString("^")
+	for i := 0; i < len(pattern); {
+		if pattern[i] == ':' {
+			j := i + 1
+			for j < len(pattern) && isParamNameChar(pattern[j]) {
+				j++
+			}
+			if j == i+1 {
+				panic(fmt.Sprintf("tgr: start payload %q: empty parameter name", pattern))
+			}
+			params = append(params, pattern[i+1:j])
+			b.WriteString("(.+?)")
+			i = j
+			continue
+		}
+		if pattern[i] == '*' {
+			b.WriteString(".*")
+			i++
+			continue
+		}
+		_, size := utf8.DecodeRuneInString(pattern[i:])
+		b.WriteString(regexp.QuoteMeta(pattern[i : i+size]))
+		i += size
+	}
+	b.WriteString("$")
+	return regexp.MustCompile(b.String()), params
+}
+
+func isParamNameChar(ch byte) bool {
+	return ch >= 'a' && ch <= 'z' || ch >= 'A' && ch <= 'Z' || ch >= '0' && ch <= '9' || ch == '_'
+}
+
+// match 匹配深度链接参数，返回提取的路由参数
+func (r *StartPayloadRoute) match(payload string) (map[string]string, bool) {
+	m := r.regex.FindStringSubmatch(payload)
+	if m == nil {
+		return nil, false
+	}
+	params := make(map[string]string, len(r.params))
+	for i, name := range r.params {
+		params[name] = m[i+1]
+	}
+	return params, true
+}
+
+// StartPayload 注册 /start 深度链接路由。
+// 模式可以包含 ":name" 参数与 "*" 通配符，参数名可以使用字母、数字与下划线，参数值通过 c.Param 获取。
+// 深度链接路由按注册顺序在 Command("start") 之前匹配，都不匹配时才交给 Command("start")。
+//
+// 示例:
+//
+//	router.StartPayload("ref-:ref_code", func(c *Context) {
+//	    code := c.Param("ref_code")
+//	})
+func (g *RouterGroup) StartPayload(pattern string, handlers ...HandlerFunc) *RouteHandle {
+	regex, params := compileStartPattern(pattern)
+	t := g.router
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	r := &route{kind: "startPayload", pattern: pattern, group: g, handlers: handlers}
+	t.startPayloadRoutes = append(t.startPayloadRoutes, &StartPayloadRoute{route: r, regex: regex, params: params})
+	t.composedDirty = true
+	return t.register(r, removeFunc(&t.startPayloadRoutes, func(sr *StartPayloadRoute) bool { return sr.route == r }))
+}
+
+// StartPayload 返回 /start 命令携带的深度链接参数，不是 /start 命令时返回空字符串
+func (c *Context) StartPayload() string {
+	if c.Message == nil || !c.Message.IsCommand() || c.Message.Command() != "start" {
+		return ""
+	}
+	return strings.TrimSpace(c.Message.CommandArguments())
+}
+
+// EncodeStartPayload 使用 base64url（无填充）编码任意数据，使其符合深度链接参数的字符限制
+func EncodeStartPayload(data string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(data))
+}
+
+// DecodeStartPayload 解码 EncodeStartPayload 编码的深度链接参数
+func DecodeStartPayload(payload string) (string, error) {
+	b, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return "", fmt.Errorf("invalid start payload: %w", err)
+	}
+	return string(b), nil
+}
+
+// StartLink 构建 https://t.me/<bot>?start=<payload> 深度链接
+// payload 只能包含 A-Z、a-z、0-9、_ 和 -，最长 64 字节；任意数据请先用 EncodeStartPayload 编码
+func (t *TelegramRouter) StartLink(payload string) (string, error) {
+	username := t.botUsername()
+	if username == "" {
+		return "", fmt.Errorf("bot username is unknown")
+	}
+	if len(payload) > MaxStartPayloadLen {
+		return "", fmt.Errorf("start payload is %d bytes, limit is %d", len(payload), MaxStartPayloadLen)
+	}
+	if !startPayloadChars.MatchString(payload) {
+		return "", fmt.Errorf("start payload %q contains characters outside [A-Za-z0-9_-]", payload)
+	}
+	return "https://t.me/" + url.PathEscape(username) + "?start=" + payload, nil
+}
+
+// botUsername 返回机器人自身的用户名（来自 Bot.Self），未知时返回空字符串
+func (t *TelegramRouter) botUsername() string {
+	if t.Bot == nil {
+		return ""
+	}
+	return t.Bot.Self.UserName
+}
+
+// commandForOtherBot 判断命令是否通过 "/cmd@OtherBot" 指定给了其他机器人
+func (t *TelegramRouter) commandForOtherBot(msg *tgbotapi.Message) bool {
+	_, target, ok := strings.Cut(msg.CommandWithAt(), "@")
+	if !ok || target == "" {
+		return false
+	}
+	username := t.botUsername()
+	return username != "" && !strings.EqualFold(target, username)
+}
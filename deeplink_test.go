@@ -0,0 +1,18 @@
+package tgr
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestStartPayloadParams(t *testing.T) {
+	router := NewTelegramRouter(nil)
+	var got map[string]string
+	router.StartPayload("ref-:ref_code", func(c *Context) {
+		got = map[string]string{"ref_code": c.Param("ref_code")}
+	})
+	router.HandleUpdate(commandUpdate("/start ref-abc_1"))
+	if want := map[string]string{"ref_code": "abc_1"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("params %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,57 @@
+package tgr
+
+// SetRouteEditedMessages 设置是否将所有编辑后的消息交给命令、文本、媒体等路由处理。
+// 开启后编辑后的消息在 OnEditedMessage 处理函数之后按普通消息分发，
+// c.Message 指向编辑后的消息，c.IsEdited() 返回 true。默认关闭。
+func (t *TelegramRouter) SetRouteEditedMessages(enabled bool) *TelegramRouter {
+	t.mu.Lock()
+	t.routeEdits = enabled
+	t.mu.Unlock()
+	return t
+}
+
+// Edited 返回同时接收编辑后消息的路由分组，只对分组内注册的路由开启编辑消息分发。
+//
+// 示例:
+//
+//	// 用户修改命令中的错别字后重新执行
+//	router.Edited().Command("search", func(c *Context) {
+//	    if c.IsEdited() {
+//	        // ...
+//	    }
+//	})
+func (g *RouterGroup) Edited() *RouterGroup {
+	t := g.router
+	t.mu.Lock()
+	t.editRoutes = true
+	t.mu.Unlock()
+	return &RouterGroup{
+		router: t,
+		parent: g,
+		edits:  true,
+	}
+}
+
+// IsEdited 当前消息是否为编辑后的消息（按普通消息路由分发时）
+func (c *Context) IsEdited() bool {
+	return c.edited
+}
+
+// routeEdited 开启编辑消息分发时，将 c.Update 替换为以编辑后消息作为 Message 的副本
+func (t *TelegramRouter) routeEdited(c *Context) {
+	update := c.Update
+	if update.EditedMessage == nil || update.Message != nil {
+		return
+	}
+	t.mu.RLock()
+	all, some := t.routeEdits, t.editRoutes
+	t.mu.RUnlock()
+	if !all && !some {
+		return
+	}
+	edited := *update
+	edited.Message = update.EditedMessage
+	c.Update = &edited
+	c.edited = true
+	c.allEdits = all
+}
@@ -0,0 +1,203 @@
+package tgr
+
+import (
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Filter 更新谓词，返回 true 表示该更新匹配。
+// 可以通过 And、Or、Not 组合内置过滤器或自定义函数。
+//
+// 示例:
+//
+//	// 私聊中带说明文字的图片
+//	router.On(tgr.And(tgr.ChatType("private"), tgr.HasPhoto(), tgr.HasCaption()), handler)
+type Filter func(update *tgbotapi.Update) bool
+
+// FilterRoute 谓词路由
+type FilterRoute struct {
+	*route                 // 路由注册
+	filter  Filter         // 匹配谓词
+	handler HandlerFunc    // 组合中间件后的处理函数
+	scope   scope          // 组合后的路由作用域
+	policy  DispatchPolicy // 组合后的分发策略
+}
+
+// On 注册谓词路由。
+// 谓词路由在通用更新处理器（OnUpdate）之后、按类型分发之前按注册顺序匹配，
+// 第一个匹配的路由执行后不再继续按类型分发。
+// 与其他路由一样，频道消息只交给 Channel 分组中的谓词路由，
+// 编辑后的消息只在开启编辑消息分发（SetRouteEditedMessages、Edited）时匹配。
+// 同一路由的多个处理函数共用一条处理链，按顺序执行，直到被中断。
+//
+// 示例:
+//
+//	router.On(tgr.And(tgr.ChatType("private"), tgr.HasPhoto(), tgr.HasCaption()), func(c *Context) {
+//	    c.Reply("收到带说明的图片：" + c.Message.Caption).Send()
+//	})
+func (g *RouterGroup) On(filter Filter, handlers ...HandlerFunc) *RouteHandle {
+	if filter == nil {
+		return nil
+	}
+	t := g.router
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	r := &route{kind: "on", pattern: funcName(filter), group: g, handlers: handlers}
+	t.filterRoutes = append(t.filterRoutes, &FilterRoute{route: r, filter: filter})
+	t.composedDirty = true
+	return t.register(r, removeFunc(&t.filterRoutes, func(fr *FilterRoute) bool { return fr.route == r }))
+}
+
+// runFilterRoutes 按注册顺序执行匹配的谓词路由，返回是否停止分发。
+// 编辑后的消息与按类型分发的路由一样，只在开启编辑消息分发时交给对应作用域的路由；
+// 匹配结束后恢复原更新，之后仍先交给 OnEditedMessage 处理函数。
+func (t *TelegramRouter) runFilterRoutes(c *Context, rt *routeTable) bool {
+	if len(rt.filterRoutesC) == 0 {
+		return false
+	}
+	update := c.Update
+	t.routeEdited(c)
+	if update.EditedMessage != nil && update.Message == nil && !c.edited {
+		return false
+	}
+	for _, route := range rt.filterRoutesC {
+		if route.scope.match(c) && route.filter(update) {
+			c.matched = true
+			route.handler(c)
+			if route.policy == DispatchDefault || t.settle(c, route.policy) {
+				return true
+			}
+		}
+	}
+	c.Update, c.edited, c.allEdits = update, false, false
+	return false
+}
+
+// updateMessage 返回更新中携带的消息（普通消息、编辑消息、频道消息、编辑的频道消息）
+func updateMessage(u *tgbotapi.Update) *tgbotapi.Message {
+	switch {
+	case u.Message != nil:
+		return u.Message
+	case u.EditedMessage != nil:
+		return u.EditedMessage
+	case u.ChannelPost != nil:
+		return u.ChannelPost
+	case u.EditedChannelPost != nil:
+		return u.EditedChannelPost
+	}
+	return nil
+}
+
+// updateChat 返回更新所在的聊天，无法确定时返回 nil
+func updateChat(u *tgbotapi.Update) *tgbotapi.Chat {
+	if m := updateMessage(u); m != nil {
+		return m.Chat
+	}
+	switch {
+	case u.CallbackQuery != nil && u.CallbackQuery.Message != nil:
+		return u.CallbackQuery.Message.Chat
+	case u.MyChatMember != nil:
+		return &u.MyChatMember.Chat
+	case u.ChatMember != nil:
+		return &u.ChatMember.Chat
+	case u.ChatJoinRequest != nil:
+		return &u.ChatJoinRequest.Chat
+	}
+	return nil
+}
+
+// ChatType 匹配指定类型的聊天："private"、"group"、"supergroup"、"channel"
+func ChatType(types ...string) Filter {
+	return func(u *tgbotapi.Update) bool {
+		chat := updateChat(u)
+		if chat == nil {
+			return false
+		}
+		for _, t := range types {
+			if chat.Type == t {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// FromUser 匹配指定用户发送的更新
+func FromUser(ids ...int64) Filter {
+	return func(u *tgbotapi.Update) bool {
+		from := u.SentFrom()
+		if from == nil {
+			return false
+		}
+		for _, id := range ids {
+			if from.ID == id {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// HasPhoto 匹配带图片的消息
+func HasPhoto() Filter {
+	return func(u *tgbotapi.Update) bool {
+		m := updateMessage(u)
+		return m != nil && len(m.Photo) > 0
+	}
+}
+
+// HasCaption 匹配带说明文字的媒体消息
+func HasCaption() Filter {
+	return func(u *tgbotapi.Update) bool {
+		m := updateMessage(u)
+		return m != nil && m.Caption != ""
+	}
+}
+
+// TextPrefix 匹配文本以指定前缀开头的消息
+func TextPrefix(prefix string) Filter {
+	return func(u *tgbotapi.Update) bool {
+		m := updateMessage(u)
+		return m != nil && m.Text != "" && strings.HasPrefix(m.Text, prefix)
+	}
+}
+
+// IsReply 匹配回复其他消息的消息
+func IsReply() Filter {
+	return func(u *tgbotapi.Update) bool {
+		m := updateMessage(u)
+		return m != nil && m.ReplyToMessage != nil
+	}
+}
+
+// And 所有过滤器都匹配时才匹配
+func And(filters ...Filter) Filter {
+	return func(u *tgbotapi.Update) bool {
+		for _, f := range filters {
+			if !f(u) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// Or 任一过滤器匹配即匹配
+func Or(filters ...Filter) Filter {
+	return func(u *tgbotapi.Update) bool {
+		for _, f := range filters {
+			if f(u) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// Not 对过滤器取反
+func Not(filter Filter) Filter {
+	return func(u *tgbotapi.Update) bool {
+		return !filter(u)
+	}
+}
@@ -0,0 +1,190 @@
+package tgr
+
+import (
+	"reflect"
+	"testing"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+func photoUpdate(caption string) *tgbotapi.Update {
+	return &tgbotapi.Update{Message: &tgbotapi.Message{
+		Photo:   []tgbotapi.PhotoSize{{FileID: "p"}},
+		Caption: caption,
+		Chat:    testChat(),
+		From:    &tgbotapi.User{ID: 7},
+	}}
+}
+
+func TestFilters(t *testing.T) {
+	always := func(*tgbotapi.Update) bool { return true }
+	never := func(*tgbotapi.Update) bool { return false }
+	reply := textUpdate("yes")
+	reply.Message.ReplyToMessage = &tgbotapi.Message{MessageID: 1}
+	channelPhoto := &tgbotapi.Update{ChannelPost: photoUpdate("").Message}
+	channelPhoto.ChannelPost.Chat = &tgbotapi.Chat{ID: 2, Type: "channel"}
+
+	tests := []struct {
+		name   string
+		filter Filter
+		update *tgbotapi.Update
+		want   bool
+	}{
+		{name: "chat type", filter: ChatType("group", "private"), update: textUpdate("hi"), want: true},
+		{name: "chat type mismatch", filter: ChatType("group"), update: textUpdate("hi")},
+		{name: "chat type of callback", filter: ChatType("private"), update: callbackUpdate("x"), want: true},
+		{name: "chat type of channel post", filter: ChatType("channel"), update: channelPhoto, want: true},
+		{name: "chat type without chat", filter: ChatType("private"), update: &tgbotapi.Update{}},
+		{name: "from user", filter: FromUser(1, 7), update: textUpdate("hi"), want: true},
+		{name: "from other user", filter: FromUser(8), update: textUpdate("hi")},
+		{name: "from user without sender", filter: FromUser(7), update: &tgbotapi.Update{}},
+		{name: "has photo", filter: HasPhoto(), update: photoUpdate(""), want: true},
+		{name: "has photo in channel post", filter: HasPhoto(), update: channelPhoto, want: true},
+		{name: "has photo on text", filter: HasPhoto(), update: textUpdate("hi")},
+		{name: "has caption", filter: HasCaption(), update: photoUpdate("look"), want: true},
+		{name: "has empty caption", filter: HasCaption(), update: photoUpdate("")},
+		{name: "text prefix", filter: TextPrefix("he"), update: textUpdate("hello"), want: true},
+		{name: "text prefix mismatch", filter: TextPrefix("he"), update: textUpdate("oh hello")},
+		{name: "text prefix on photo", filter: TextPrefix(""), update: photoUpdate("caption")},
+		{name: "is reply", filter: IsReply(), update: reply, want: true},
+		{name: "is not reply", filter: IsReply(), update: textUpdate("no")},
+		{name: "and", filter: And(ChatType("private"), HasPhoto(), HasCaption()), update: photoUpdate("look"), want: true},
+		{name: "and with one mismatch", filter: And(always, never), update: textUpdate("hi")},
+		{name: "empty and", filter: And(), update: textUpdate("hi"), want: true},
+		{name: "or", filter: Or(never, HasPhoto()), update: photoUpdate(""), want: true},
+		{name: "or without match", filter: Or(never, HasPhoto()), update: textUpdate("hi")},
+		{name: "empty or", filter: Or(), update: textUpdate("hi")},
+		{name: "not", filter: Not(HasPhoto()), update: textUpdate("hi"), want: true},
+		{name: "nested", filter: Or(And(never, always), Not(never)), update: textUpdate("hi"), want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.filter(tt.update); got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFilterRouteDispatch(t *testing.T) {
+	always := func(*tgbotapi.Update) bool { return true }
+	channelPhoto := func() *tgbotapi.Update {
+		m := photoUpdate("").Message
+		m.Chat = &tgbotapi.Chat{ID: 2, Type: "channel"}
+		return &tgbotapi.Update{ChannelPost: m}
+	}
+	edited := func() *tgbotapi.Update {
+		return &tgbotapi.Update{EditedMessage: textUpdate("typo").Message}
+	}
+	tests := []struct {
+		name   string
+		setup  func(router *TelegramRouter, rec *recorder)
+		update *tgbotapi.Update
+		want   []string
+	}{
+		{
+			name: "before typed routes",
+			setup: func(router *TelegramRouter, rec *recorder) {
+				router.Photo(rec.handler("photo"))
+				router.On(HasPhoto(), rec.handler("on"))
+			},
+			update: photoUpdate(""),
+			want:   []string{"on"},
+		},
+		{
+			name: "no match falls through to typed routes",
+			setup: func(router *TelegramRouter, rec *recorder) {
+				router.On(HasPhoto(), rec.handler("on"))
+				router.Text(rec.handler("text"))
+			},
+			update: textUpdate("hi"),
+			want:   []string{"text"},
+		},
+		{
+			name: "after OnUpdate, first match in registration order",
+			setup: func(router *TelegramRouter, rec *recorder) {
+				router.On(always, rec.handler("a"))
+				router.On(always, rec.handler("b"))
+				router.OnUpdate(rec.handler("update"))
+			},
+			update: textUpdate("hi"),
+			want:   []string{"update", "a"},
+		},
+		{
+			name: "all match continues to typed routes",
+			setup: func(router *TelegramRouter, rec *recorder) {
+				router.WithDispatchPolicy(AllMatch).On(always, rec.handler("on"))
+				router.Text(rec.handler("text"))
+			},
+			update: textUpdate("hi"),
+			want:   []string{"on", "text"},
+		},
+		{
+			name: "channel post skips root scope",
+			setup: func(router *TelegramRouter, rec *recorder) {
+				router.On(HasPhoto(), rec.handler("on"))
+				router.Channel().Photo(rec.handler("channel photo"))
+			},
+			update: channelPhoto(),
+			want:   []string{"channel photo"},
+		},
+		{
+			name: "channel post reaches channel group",
+			setup: func(router *TelegramRouter, rec *recorder) {
+				router.Channel().On(HasPhoto(), rec.handler("channel on"))
+				router.Channel().Photo(rec.handler("channel photo"))
+			},
+			update: channelPhoto(),
+			want:   []string{"channel on"},
+		},
+		{
+			name: "edited message not routed",
+			setup: func(router *TelegramRouter, rec *recorder) {
+				router.On(always, rec.handler("on"))
+				router.OnEditedMessage(rec.handler("edited"))
+			},
+			update: edited(),
+			want:   []string{"edited"},
+		},
+		{
+			name: "edited message in edited group",
+			setup: func(router *TelegramRouter, rec *recorder) {
+				router.On(always, rec.handler("on"))
+				router.Edited().On(TextPrefix("ty"), func(c *Context) {
+					if c.IsEdited() && c.Message != nil {
+						rec.log = append(rec.log, "edited on")
+					}
+				})
+			},
+			update: edited(),
+			want:   []string{"edited on"},
+		},
+		{
+			name: "edited message routed everywhere",
+			setup: func(router *TelegramRouter, rec *recorder) {
+				router.SetRouteEditedMessages(true)
+				router.WithDispatchPolicy(AllMatch).On(always, rec.handler("on"))
+				router.OnEditedMessage(func(c *Context) {
+					// 谓词路由结束后恢复原更新
+					if !c.IsEdited() && c.Message == nil {
+						rec.log = append(rec.log, "edited")
+					}
+				})
+				router.Text(rec.handler("text"))
+			},
+			update: edited(),
+			want:   []string{"on", "edited", "text"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := NewTelegramRouter(nil)
+			rec := &recorder{}
+			tt.setup(router, rec)
+			router.HandleUpdate(tt.update)
+			if !reflect.DeepEqual(rec.log, tt.want) {
+				t.Errorf("ran %v, want %v", rec.log, tt.want)
+			}
+		})
+	}
+}
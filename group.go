@@ -0,0 +1,183 @@
+package tgr
+
+// RouterGroup 路由分组。
+// 分组提供与 TelegramRouter 相同的注册方法（Command、Text、Callback、Photo 等），
+// 在分组内注册的处理函数会在全局中间件之后、处理函数之前执行分组中间件，
+// 嵌套分组按由外到内的顺序执行各级中间件。
+//
+// 分组中间件与全局中间件一样，只在组合缓存（composeHandlers）时拼接一次，
+// 分发时不会重复构建处理链。
+type RouterGroup struct {
+	router      *TelegramRouter
+	parent      *RouterGroup
+	middlewares []HandlerFunc
+	state       string // 会话状态作用域，hasState 为 true 时生效
+	hasState    bool
+	edits       bool // 是否同时接收编辑后的消息
+	channel     bool // 是否为频道消息分组
+	policy      DispatchPolicy
+	chatTypes   chatTypeMask // 限定的聊天类型，0 表示不限定
+}
+
+// scope 路由作用域，由注册时所在的路由分组决定
+type scope struct {
+	state     string
+	hasState  bool
+	edits     bool
+	channel   bool
+	chatTypes chatTypeMask
+}
+
+// specific 是否为带条件的作用域（会话状态、聊天类型），带条件的处理函数优先于普通处理函数
+func (s scope) specific() bool {
+	return s.hasState || s.chatTypes != 0
+}
+
+// match 检查当前更新是否处于该作用域
+func (s scope) match(c *Context) bool {
+	// 频道消息只交给频道分组（Channel）或限定了频道类型的分组（Channels）
+	if c.channel && !s.channel && !s.chatTypes.has("channel") || !c.channel && s.channel {
+		return false
+	}
+	if s.chatTypes != 0 {
+		chat := updateChat(c.Update)
+		if chat == nil || !s.chatTypes.has(chat.Type) {
+			return false
+		}
+	}
+	if c.edited && !s.edits && !c.allEdits {
+		return false
+	}
+	if s.hasState && c.State() != s.state {
+		return false
+	}
+	return true
+}
+
+// composedHandler 组合中间件后的处理函数及其作用域
+type composedHandler struct {
+	scope   scope
+	handler HandlerFunc
+	match   func(*Context) bool // 额外的匹配条件，为 nil 时总是匹配
+	policy  DispatchPolicy
+	route   *route // 所属的注册，同一次注册的多个处理函数属于同一个路由
+}
+
+// Group 创建一个子分组，子分组继承当前分组的中间件。
+//
+// 示例:
+//
+//	admin := router.Group(RequireAdmin)
+//	admin.Command("ban", banHandler)
+//	admin.Callback("admin/:action", adminActionHandler)
+//
+//	// 嵌套分组：先执行 RequireAdmin，再执行 AuditLog
+//	audited := admin.Group(AuditLog)
+//	audited.Command("purge", purgeHandler)
+func (g *RouterGroup) Group(middlewares ...HandlerFunc) *RouterGroup {
+	return &RouterGroup{
+		router:      g.router,
+		parent:      g,
+		middlewares: middlewares,
+	}
+}
+
+// Use 为分组追加中间件，支持链式调用。
+// 追加的中间件对分组内已注册和之后注册的处理函数都生效。
+func (g *RouterGroup) Use(middlewares ...HandlerFunc) *RouterGroup {
+	t := g.router
+	t.mu.Lock()
+	g.middlewares = append(g.middlewares, middlewares...)
+	t.composedDirty = true
+	t.mu.Unlock()
+	return g
+}
+
+// scope 计算分组的作用域，最近一级分组设置的会话状态生效，编辑消息与频道开关由外层继承，聊天类型取各级的交集
+func (g *RouterGroup) scope() scope {
+	if g == nil {
+		return scope{}
+	}
+	s := g.parent.scope()
+	if g.hasState {
+		s.state, s.hasState = g.state, true
+	}
+	s.edits = s.edits || g.edits
+	s.channel = s.channel || g.channel
+	if g.chatTypes != 0 {
+		if s.chatTypes != 0 {
+			s.chatTypes &= g.chatTypes
+		} else {
+			s.chatTypes = g.chatTypes
+		}
+	}
+	return s
+}
+
+// chain 返回分组（含所有父分组）的中间件，按由外到内的顺序排列
+func (g *RouterGroup) chain() []HandlerFunc {
+	if g == nil {
+		return nil
+	}
+	return append(g.parent.chain(), g.middlewares...)
+}
+
+// add 在写锁下登记一次注册，并标记组合缓存失效
+func (g *RouterGroup) add(kind string, list *[]*route, handlers []HandlerFunc) *RouteHandle {
+	return g.addRoute(list, &route{kind: kind, group: g, handlers: handlers})
+}
+
+// addMatch 登记一个带额外匹配条件的注册，条件不满足时该路由不执行（也不执行其中间件）
+func (g *RouterGroup) addMatch(kind, pattern string, list *[]*route, match func(*Context) bool, handlers ...HandlerFunc) *RouteHandle {
+	return g.addRoute(list, &route{kind: kind, pattern: pattern, group: g, handlers: handlers, match: match})
+}
+
+// addRoute 在写锁下将注册追加到列表
+func (g *RouterGroup) addRoute(list *[]*route, r *route) *RouteHandle {
+	t := g.router
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	*list = append(*list, r)
+	t.composedDirty = true
+	return t.register(r, removeFunc(list, func(x *route) bool { return x == r }))
+}
+
+// run 执行匹配当前作用域的路由，返回是否有处理函数被执行（并记录到 c.matched）。
+// 带条件作用域（如会话状态、聊天类型）的路由优先执行；每个路由执行完后按其分发策略决定是否继续。
+// 默认策略与 FirstMatch 下带条件的路由有匹配时不再执行普通路由，
+// AllMatch 与 FallThroughUntilAbort 下继续执行普通路由，直到策略要求停止。
+func (t *TelegramRouter) run(c *Context, hs []composedHandler) bool {
+	ran, stopped := t.runTier(c, hs, true)
+	if stopped || ran && (c.lastPolicy == DispatchDefault || c.lastPolicy == FirstMatch) {
+		return ran
+	}
+	more, _ := t.runTier(c, hs, false)
+	return ran || more
+}
+
+// runTier 执行一层作用域中匹配的路由，返回是否有路由执行以及分发策略是否要求停止
+func (t *TelegramRouter) runTier(c *Context, hs []composedHandler, specific bool) (ran, stopped bool) {
+	for i := 0; i < len(hs); i++ {
+		h := hs[i]
+		if h.scope.specific() != specific || !h.scope.match(c) || h.match != nil && !h.match(c) {
+			continue
+		}
+		ran = true
+		c.matched = true
+		c.lastPolicy = h.policy
+		h.handler(c)
+		// 路由被中断时跳过同一次注册的其余处理函数
+		last := i+1 == len(hs) || hs[i+1].route != h.route
+		if !last && !c.IsAborted() {
+			continue
+		}
+		for !last {
+			i++
+			last = i+1 == len(hs) || hs[i+1].route != h.route
+		}
+		if t.settle(c, h.policy) {
+			return true, true
+		}
+	}
+	return ran, false
+}
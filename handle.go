@@ -0,0 +1,82 @@
+package tgr
+
+import "slices"
+
+// RouteHandle 路由注册句柄，所有注册方法都会返回，用于在运行时移除或替换路由（如功能开关、插件重载）。
+// 修改会使路由表在下一次分发前重新组合并原子替换，正在处理的更新继续使用修改前的路由表。
+//
+// 示例:
+//
+//	h := router.Command("beta", betaHandler)
+//	// 关闭功能
+//	h.Remove()
+type RouteHandle struct {
+	router *TelegramRouter
+	route  *route
+	detach func() // 从所属的注册列表中移除，调用方需持有写锁
+}
+
+// Remove 移除路由，返回是否移除成功（重复移除返回 false）
+func (h *RouteHandle) Remove() bool {
+	if h == nil {
+		return false
+	}
+	t := h.router
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if h.route.removed {
+		return false
+	}
+	h.route.removed = true
+	h.detach()
+	t.registry = slices.DeleteFunc(t.registry, func(r *route) bool { return r == h.route })
+	t.composedDirty = true
+	return true
+}
+
+// Replace 替换路由的处理函数，路由的类型、模式、分组与注册顺序保持不变。
+// 路由已被移除时返回 false。
+func (h *RouteHandle) Replace(handlers ...HandlerFunc) bool {
+	if h == nil {
+		return false
+	}
+	t := h.router
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if h.route.removed {
+		return false
+	}
+	h.route.handlers = handlers
+	t.composedDirty = true
+	return true
+}
+
+// Info 返回路由的描述信息
+func (h *RouteHandle) Info() RouteInfo {
+	if h == nil {
+		return RouteInfo{}
+	}
+	t := h.router
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.routeInfo(h.route)
+}
+
+// removeFunc 返回从列表中移除满足条件元素的函数
+func removeFunc[T any](list *[]T, del func(T) bool) func() {
+	return func() {
+		*list = slices.DeleteFunc(*list, del)
+	}
+}
+
+// removeKeyFunc 返回从按键分组的列表中移除路由的函数，列表为空时删除该键
+func removeKeyFunc[K comparable](m map[K][]*route, key K, r *route) func() {
+	return func() {
+		list := slices.DeleteFunc(m[key], func(x *route) bool { return x == r })
+		if len(list) == 0 {
+			delete(m, key)
+			return
+		}
+		m[key] = list
+	}
+}
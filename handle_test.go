@@ -0,0 +1,180 @@
+package tgr
+
+import (
+	"reflect"
+	"regexp"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+func TestRouteHandleRemove(t *testing.T) {
+	tests := []struct {
+		name     string
+		register func(router *TelegramRouter, h HandlerFunc) *RouteHandle
+		update   func() *tgbotapi.Update
+	}{
+		{
+			name:     "command",
+			register: func(router *TelegramRouter, h HandlerFunc) *RouteHandle { return router.Command("go", h) },
+			update:   func() *tgbotapi.Update { return commandUpdate("/go") },
+		},
+		{
+			name: "command regex",
+			register: func(router *TelegramRouter, h HandlerFunc) *RouteHandle {
+				return router.CommandRegex(regexp.MustCompile(`^g`), h)
+			},
+			update: func() *tgbotapi.Update { return commandUpdate("/go") },
+		},
+		{
+			name:     "callback",
+			register: func(router *TelegramRouter, h HandlerFunc) *RouteHandle { return router.Callback("item/:id", h) },
+			update:   func() *tgbotapi.Update { return callbackUpdate("item/1") },
+		},
+		{
+			name:     "text",
+			register: func(router *TelegramRouter, h HandlerFunc) *RouteHandle { return router.Text(h) },
+			update:   func() *tgbotapi.Update { return textUpdate("hi") },
+		},
+		{
+			name: "filter",
+			register: func(router *TelegramRouter, h HandlerFunc) *RouteHandle {
+				return router.On(func(u *tgbotapi.Update) bool { return true }, h)
+			},
+			update: func() *tgbotapi.Update { return textUpdate("hi") },
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := NewTelegramRouter(nil)
+			rec := &recorder{}
+			router.NoRoute(rec.handler("noroute"))
+			h := tt.register(router, rec.handler("route"))
+			router.HandleUpdate(tt.update())
+			if !h.Remove() {
+				t.Fatal("Remove() = false")
+			}
+			router.HandleUpdate(tt.update())
+			if want := []string{"route", "noroute"}; !reflect.DeepEqual(rec.log, want) {
+				t.Errorf("ran %v, want %v", rec.log, want)
+			}
+			for _, info := range router.Routes() {
+				if info.Kind != "noRoute" {
+					t.Errorf("route %+v still registered", info)
+				}
+			}
+		})
+	}
+}
+
+func TestRouteHandleRemoveCommandKey(t *testing.T) {
+	router := NewTelegramRouter(nil)
+	rec := &recorder{}
+	a := router.Command("go", rec.handler("a"))
+	b := router.Command("go", rec.handler("b"))
+	a.Remove()
+	if got := len(router.commandHandlers["go"]); got != 1 {
+		t.Fatalf("%d routes left for the command, want 1", got)
+	}
+	router.HandleUpdate(commandUpdate("/go"))
+	b.Remove()
+	if _, ok := router.commandHandlers["go"]; ok {
+		t.Error("empty command key left in the map")
+	}
+	router.HandleUpdate(commandUpdate("/go"))
+	if want := []string{"b"}; !reflect.DeepEqual(rec.log, want) {
+		t.Errorf("ran %v, want %v", rec.log, want)
+	}
+}
+
+func TestRouteHandleRemoveRebuildsCallbackTrie(t *testing.T) {
+	router := NewTelegramRouter(nil)
+	rec := &recorder{}
+	static := router.Callback("item/new", rec.handler("static"))
+	router.Callback("item/:id", rec.handler("param"))
+	router.HandleUpdate(callbackUpdate("item/new"))
+	static.Remove()
+	// 移除静态路由后同一数据回退到参数路由
+	router.HandleUpdate(callbackUpdate("item/new"))
+	if want := []string{"static", "param"}; !reflect.DeepEqual(rec.log, want) {
+		t.Errorf("ran %v, want %v", rec.log, want)
+	}
+}
+
+func TestRouteHandleReplace(t *testing.T) {
+	router := NewTelegramRouter(nil)
+	rec := &recorder{}
+	router.Text(rec.handler("a"))
+	h := router.Text(rec.handler("b"))
+	router.Text(rec.handler("c"))
+	order := h.Info().Order
+
+	if !h.Replace(rec.handler("b2"), rec.handler("b3")) {
+		t.Fatal("Replace() = false")
+	}
+	router.HandleUpdate(textUpdate("hi"))
+	if want := []string{"a", "b2", "b3", "c"}; !reflect.DeepEqual(rec.log, want) {
+		t.Errorf("ran %v, want %v", rec.log, want)
+	}
+	if info := h.Info(); info.Order != order || info.Kind != "text" || len(info.Handlers) != 2 {
+		t.Errorf("info after Replace %+v, want order %d", info, order)
+	}
+	if h.Remove(); h.Replace(rec.handler("x")) {
+		t.Error("Replace() after Remove = true")
+	}
+}
+
+func TestRouteHandleDoubleRemove(t *testing.T) {
+	router := NewTelegramRouter(nil)
+	rec := &recorder{}
+	h := router.Text(rec.handler("a"))
+	router.Text(rec.handler("b"))
+	if !h.Remove() {
+		t.Fatal("first Remove() = false")
+	}
+	if h.Remove() {
+		t.Error("second Remove() = true")
+	}
+	var nilHandle *RouteHandle
+	if nilHandle.Remove() || nilHandle.Replace() {
+		t.Error("nil handle reported success")
+	}
+	router.HandleUpdate(textUpdate("hi"))
+	if want := []string{"b"}; !reflect.DeepEqual(rec.log, want) || len(router.Routes()) != 1 {
+		t.Errorf("ran %v with %d routes, want %v and 1 route", rec.log, len(router.Routes()), want)
+	}
+}
+
+func TestRouteHandleConcurrentDispatch(t *testing.T) {
+	router := NewTelegramRouter(nil)
+	var kept, removed atomic.Int64
+	router.Text(func(c *Context) { kept.Add(1) })
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 200; j++ {
+				router.HandleUpdate(textUpdate("hi"))
+			}
+		}()
+	}
+	for i := 0; i < 100; i++ {
+		h := router.Text(func(c *Context) { removed.Add(1) })
+		h.Replace(func(c *Context) { removed.Add(1) })
+		h.Remove()
+	}
+	wg.Wait()
+
+	if kept.Load() != 800 {
+		t.Errorf("kept route ran %d times, want 800", kept.Load())
+	}
+	before := removed.Load()
+	router.HandleUpdate(textUpdate("hi"))
+	if removed.Load() != before {
+		t.Error("removed route ran after all removals")
+	}
+}
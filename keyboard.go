@@ -0,0 +1,226 @@
+package tgr
+
+import (
+	"fmt"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// InlineKeyboardBuilder 内联键盘构建器。
+// 按钮依次追加到当前行，设置 Columns 后每行满 n 个按钮自动换行，Row 手动开始新的一行。
+// 添加按钮时的错误（如回调数据超过 64 字节）会保留到 Build 时返回。
+//
+// 示例:
+//
+//	kb, err := router.InlineKeyboard().Columns(2).
+//	    Callback("确认", "order/42/confirm").
+//	    CallbackRoute("取消", "order/:id/status", OrderStatus{ID: 42, Status: "canceled"}).
+//	    Row().
+//	    URL("帮助", "https://example.com/help").
+//	    Build()
+//	c.Reply("请选择").WithInlineKeyboard(kb).Send()
+//	c.EditMessageText("已更新", &tgr.EditOptions{ReplyMarkup: &kb})
+type InlineKeyboardBuilder struct {
+	router  *TelegramRouter
+	columns int
+	rows    [][]tgbotapi.InlineKeyboardButton
+	err     error
+}
+
+// NewInlineKeyboard 创建内联键盘构建器，回调数据只做 64 字节长度检查
+func NewInlineKeyboard() *InlineKeyboardBuilder {
+	return &InlineKeyboardBuilder{}
+}
+
+// InlineKeyboard 创建内联键盘构建器。
+// 回调按钮通过 InlineButton 与 CallbackButton 创建：设置了 CallbackStore 时超长数据存入服务端，
+// CallbackRoute 还会检查模式是否已通过 Callback 注册。
+func (t *TelegramRouter) InlineKeyboard() *InlineKeyboardBuilder {
+	return &InlineKeyboardBuilder{router: t}
+}
+
+// Columns 设置每行最多的按钮数，n <= 0 表示不自动换行
+func (b *InlineKeyboardBuilder) Columns(n int) *InlineKeyboardBuilder {
+	b.columns = n
+	return b
+}
+
+// Row 开始新的一行
+func (b *InlineKeyboardBuilder) Row() *InlineKeyboardBuilder {
+	if n := len(b.rows); n > 0 && len(b.rows[n-1]) > 0 {
+		b.rows = append(b.rows, nil)
+	}
+	return b
+}
+
+// Button 添加任意内联按钮
+func (b *InlineKeyboardBuilder) Button(btn tgbotapi.InlineKeyboardButton) *InlineKeyboardBuilder {
+	n := len(b.rows)
+	if n == 0 || b.columns > 0 && len(b.rows[n-1]) >= b.columns {
+		b.rows = append(b.rows, nil)
+		n++
+	}
+	b.rows[n-1] = append(b.rows[n-1], btn)
+	return b
+}
+
+// add 添加按钮，创建失败时记录第一个错误
+func (b *InlineKeyboardBuilder) add(btn tgbotapi.InlineKeyboardButton, err error) *InlineKeyboardBuilder {
+	if err != nil {
+		if b.err == nil {
+			b.err = err
+		}
+		return b
+	}
+	return b.Button(btn)
+}
+
+// Callback 添加回调按钮
+func (b *InlineKeyboardBuilder) Callback(text, data string) *InlineKeyboardBuilder {
+	if b.router != nil {
+		return b.add(b.router.InlineButton(text, data))
+	}
+	if len(data) > MaxCallbackDataLen {
+		return b.add(tgbotapi.InlineKeyboardButton{}, fmt.Errorf("%w: %q is %d bytes", ErrCallbackDataTooLong, data, len(data)))
+	}
+	return b.Button(tgbotapi.NewInlineKeyboardButtonData(text, data))
+}
+
+// CallbackRoute 按回调路由模式编码结构体并添加回调按钮，编码规则见 EncodeCallback
+func (b *InlineKeyboardBuilder) CallbackRoute(text, pattern string, v any) *InlineKeyboardBuilder {
+	if b.router != nil {
+		return b.add(b.router.CallbackButton(text, pattern, v))
+	}
+	return b.add(NewCallbackButton(text, pattern, v))
+}
+
+// URL 添加链接按钮
+func (b *InlineKeyboardBuilder) URL(text, url string) *InlineKeyboardBuilder {
+	return b.Button(tgbotapi.NewInlineKeyboardButtonURL(text, url))
+}
+
+// SwitchInline 添加切换到内联模式的按钮，用户选择聊天后输入框中预填 query
+func (b *InlineKeyboardBuilder) SwitchInline(text, query string) *InlineKeyboardBuilder {
+	return b.Button(tgbotapi.NewInlineKeyboardButtonSwitch(text, query))
+}
+
+// SwitchInlineCurrentChat 添加在当前聊天中切换到内联模式的按钮
+func (b *InlineKeyboardBuilder) SwitchInlineCurrentChat(text, query string) *InlineKeyboardBuilder {
+	return b.Button(tgbotapi.InlineKeyboardButton{Text: text, SwitchInlineQueryCurrentChat: &query})
+}
+
+// Build 返回内联键盘，添加按钮时出现过错误则返回第一个错误
+func (b *InlineKeyboardBuilder) Build() (tgbotapi.InlineKeyboardMarkup, error) {
+	if b.err != nil {
+		return tgbotapi.InlineKeyboardMarkup{}, b.err
+	}
+	return tgbotapi.InlineKeyboardMarkup{InlineKeyboard: b.buttons()}, nil
+}
+
+// buttons 返回去掉空行后的按钮
+func (b *InlineKeyboardBuilder) buttons() [][]tgbotapi.InlineKeyboardButton {
+	rows := make([][]tgbotapi.InlineKeyboardButton, 0, len(b.rows))
+	for _, row := range b.rows {
+		if len(row) > 0 {
+			rows = append(rows, row)
+		}
+	}
+	return rows
+}
+
+// ReplyKeyboardBuilder 回复键盘构建器，换行规则与 InlineKeyboardBuilder 相同
+//
+// 示例:
+//
+//	kb := tgr.NewReplyKeyboard().Columns(2).Resize().OneTime().
+//	    Button("菜单").Button("设置").
+//	    Row().
+//	    RequestContact("发送手机号").
+//	    Build()
+//	c.Reply("请选择").WithReplyMarkup(kb).Send()
+type ReplyKeyboardBuilder struct {
+	columns int
+	rows    [][]tgbotapi.KeyboardButton
+	markup  tgbotapi.ReplyKeyboardMarkup
+}
+
+// NewReplyKeyboard 创建回复键盘构建器
+func NewReplyKeyboard() *ReplyKeyboardBuilder {
+	return &ReplyKeyboardBuilder{}
+}
+
+// Columns 设置每行最多的按钮数，n <= 0 表示不自动换行
+func (b *ReplyKeyboardBuilder) Columns(n int) *ReplyKeyboardBuilder {
+	b.columns = n
+	return b
+}
+
+// Row 开始新的一行
+func (b *ReplyKeyboardBuilder) Row() *ReplyKeyboardBuilder {
+	if n := len(b.rows); n > 0 && len(b.rows[n-1]) > 0 {
+		b.rows = append(b.rows, nil)
+	}
+	return b
+}
+
+// Add 添加任意键盘按钮
+func (b *ReplyKeyboardBuilder) Add(btn tgbotapi.KeyboardButton) *ReplyKeyboardBuilder {
+	n := len(b.rows)
+	if n == 0 || b.columns > 0 && len(b.rows[n-1]) >= b.columns {
+		b.rows = append(b.rows, nil)
+		n++
+	}
+	b.rows[n-1] = append(b.rows[n-1], btn)
+	return b
+}
+
+// Button 添加文本按钮
+func (b *ReplyKeyboardBuilder) Button(text string) *ReplyKeyboardBuilder {
+	return b.Add(tgbotapi.NewKeyboardButton(text))
+}
+
+// RequestContact 添加请求用户手机号的按钮（仅私聊可用）
+func (b *ReplyKeyboardBuilder) RequestContact(text string) *ReplyKeyboardBuilder {
+	return b.Add(tgbotapi.NewKeyboardButtonContact(text))
+}
+
+// RequestLocation 添加请求用户位置的按钮（仅私聊可用）
+func (b *ReplyKeyboardBuilder) RequestLocation(text string) *ReplyKeyboardBuilder {
+	return b.Add(tgbotapi.NewKeyboardButtonLocation(text))
+}
+
+// Resize 让客户端按按钮数量调整键盘高度
+func (b *ReplyKeyboardBuilder) Resize() *ReplyKeyboardBuilder {
+	b.markup.ResizeKeyboard = true
+	return b
+}
+
+// OneTime 按下按钮后隐藏键盘
+func (b *ReplyKeyboardBuilder) OneTime() *ReplyKeyboardBuilder {
+	b.markup.OneTimeKeyboard = true
+	return b
+}
+
+// Selective 只对被提及或被回复的用户显示键盘
+func (b *ReplyKeyboardBuilder) Selective() *ReplyKeyboardBuilder {
+	b.markup.Selective = true
+	return b
+}
+
+// Placeholder 设置键盘显示时输入框中的占位文字
+func (b *ReplyKeyboardBuilder) Placeholder(text string) *ReplyKeyboardBuilder {
+	b.markup.InputFieldPlaceholder = text
+	return b
+}
+
+// Build 返回回复键盘
+func (b *ReplyKeyboardBuilder) Build() tgbotapi.ReplyKeyboardMarkup {
+	markup := b.markup
+	markup.Keyboard = make([][]tgbotapi.KeyboardButton, 0, len(b.rows))
+	for _, row := range b.rows {
+		if len(row) > 0 {
+			markup.Keyboard = append(markup.Keyboard, row)
+		}
+	}
+	return markup
+}
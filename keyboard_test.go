@@ -0,0 +1,198 @@
+package tgr
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// inlineTexts 返回内联键盘每行按钮的文字
+func inlineTexts(markup tgbotapi.InlineKeyboardMarkup) [][]string {
+	rows := [][]string{}
+	for _, row := range markup.InlineKeyboard {
+		var texts []string
+		for _, btn := range row {
+			texts = append(texts, btn.Text)
+		}
+		rows = append(rows, texts)
+	}
+	return rows
+}
+
+func TestInlineKeyboardLayout(t *testing.T) {
+	tests := []struct {
+		name  string
+		build func(b *InlineKeyboardBuilder) *InlineKeyboardBuilder
+		want  [][]string
+	}{
+		{
+			name:  "empty",
+			build: func(b *InlineKeyboardBuilder) *InlineKeyboardBuilder { return b.Row() },
+			want:  [][]string{},
+		},
+		{
+			name: "single row without columns",
+			build: func(b *InlineKeyboardBuilder) *InlineKeyboardBuilder {
+				return b.Callback("a", "a").Callback("b", "b").Callback("c", "c")
+			},
+			want: [][]string{{"a", "b", "c"}},
+		},
+		{
+			name: "columns wrap",
+			build: func(b *InlineKeyboardBuilder) *InlineKeyboardBuilder {
+				return b.Columns(2).Callback("a", "a").Callback("b", "b").Callback("c", "c").Callback("d", "d").Callback("e", "e")
+			},
+			want: [][]string{{"a", "b"}, {"c", "d"}, {"e"}},
+		},
+		{
+			name: "row starts a new row",
+			build: func(b *InlineKeyboardBuilder) *InlineKeyboardBuilder {
+				return b.Columns(3).Callback("a", "a").Row().URL("b", "https://example.com").SwitchInline("c", "q")
+			},
+			want: [][]string{{"a"}, {"b", "c"}},
+		},
+		{
+			name: "row on an empty row",
+			build: func(b *InlineKeyboardBuilder) *InlineKeyboardBuilder {
+				return b.Row().Callback("a", "a").Row().Row().Callback("b", "b").Row()
+			},
+			want: [][]string{{"a"}, {"b"}},
+		},
+		{
+			name: "row after wrap",
+			build: func(b *InlineKeyboardBuilder) *InlineKeyboardBuilder {
+				return b.Columns(1).Callback("a", "a").Row().Callback("b", "b")
+			},
+			want: [][]string{{"a"}, {"b"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			markup, err := tt.build(NewInlineKeyboard()).Build()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := inlineTexts(markup); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("rows %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInlineKeyboardErrors(t *testing.T) {
+	type order struct {
+		ID int `callback:"id"`
+	}
+	long := strings.Repeat("x", MaxCallbackDataLen+1)
+	newRouter := func() *TelegramRouter {
+		router := NewTelegramRouter(nil)
+		router.Callback("order/:id", func(*Context) {})
+		return router
+	}
+	tests := []struct {
+		name    string
+		build   func() *InlineKeyboardBuilder
+		wantErr error // 为 nil 时只要求返回错误
+		want    [][]string
+	}{
+		{
+			name:    "callback data too long",
+			build:   func() *InlineKeyboardBuilder { return NewInlineKeyboard().Callback("ok", "a").Callback("long", long) },
+			wantErr: ErrCallbackDataTooLong,
+		},
+		{
+			name: "router callback data too long",
+			build: func() *InlineKeyboardBuilder {
+				return newRouter().InlineKeyboard().Callback("long", long).Callback("ok", "a")
+			},
+			wantErr: ErrCallbackDataTooLong,
+		},
+		{
+			name: "router stores long callback data",
+			build: func() *InlineKeyboardBuilder {
+				return newRouter().SetCallbackStore(NewMemoryCallbackStore(), 0).InlineKeyboard().Callback("long", long)
+			},
+			want: [][]string{{"long"}},
+		},
+		{
+			name: "callback route too long",
+			build: func() *InlineKeyboardBuilder {
+				return NewInlineKeyboard().CallbackRoute("long", "order/:id/"+long, order{ID: 1})
+			},
+			wantErr: ErrCallbackDataTooLong,
+		},
+		{
+			name: "registered callback route",
+			build: func() *InlineKeyboardBuilder {
+				return newRouter().InlineKeyboard().CallbackRoute("open", "order/:id", order{ID: 1})
+			},
+			want: [][]string{{"open"}},
+		},
+		{
+			name: "unregistered callback route",
+			build: func() *InlineKeyboardBuilder {
+				return newRouter().InlineKeyboard().CallbackRoute("open", "item/:id", order{ID: 1})
+			},
+		},
+		{
+			name: "unbound builder skips registration check",
+			build: func() *InlineKeyboardBuilder {
+				return NewInlineKeyboard().CallbackRoute("open", "item/:id", order{ID: 1})
+			},
+			want: [][]string{{"open"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			markup, err := tt.build().Build()
+			if tt.want != nil {
+				if err != nil {
+					t.Fatal(err)
+				}
+				if got := inlineTexts(markup); !reflect.DeepEqual(got, tt.want) {
+					t.Errorf("rows %q, want %q", got, tt.want)
+				}
+				return
+			}
+			if err == nil || tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Build() = %v, want %v", err, tt.wantErr)
+			}
+			if len(markup.InlineKeyboard) != 0 {
+				t.Errorf("markup %+v returned with error", markup)
+			}
+		})
+	}
+}
+
+func TestReplyKeyboard(t *testing.T) {
+	markup := NewReplyKeyboard().Columns(2).Resize().OneTime().Selective().Placeholder("pick").
+		Row().
+		Button("a").Button("b").Button("c").
+		Row().Row().
+		RequestContact("phone").RequestLocation("where").
+		Build()
+
+	var got [][]string
+	for _, row := range markup.Keyboard {
+		var texts []string
+		for _, btn := range row {
+			texts = append(texts, btn.Text)
+		}
+		got = append(got, texts)
+	}
+	if want := [][]string{{"a", "b"}, {"c"}, {"phone", "where"}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("rows %q, want %q", got, want)
+	}
+	if !markup.Keyboard[2][0].RequestContact || !markup.Keyboard[2][1].RequestLocation {
+		t.Error("request buttons lost their flags")
+	}
+	if !markup.ResizeKeyboard || !markup.OneTimeKeyboard || !markup.Selective || markup.InputFieldPlaceholder != "pick" {
+		t.Errorf("markup options %+v", markup)
+	}
+	if empty := NewReplyKeyboard().Row().Build(); len(empty.Keyboard) != 0 {
+		t.Errorf("empty keyboard has rows %+v", empty.Keyboard)
+	}
+}
@@ -0,0 +1,143 @@
+package tgr
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// defaultMediaGroupWindow 媒体组默认缓冲时长
+const defaultMediaGroupWindow = time.Second
+
+// mediaGroupBuffer 同一媒体组（相册）中已收到的消息
+type mediaGroupBuffer struct {
+	updates []*tgbotapi.Update
+	channel bool // 是否为按普通消息分发的频道消息
+	timer   *time.Timer
+}
+
+// MediaGroup 注册媒体组（相册）的处理函数。
+// 同一相册的每张照片、视频或文件都会作为单独的更新到达，并带有相同的 MediaGroupID；
+// 匹配的更新会按 MediaGroupID 缓冲，在最后一条消息到达后等待一个窗口期（见 SetMediaGroupWindow）再合并为一次调用，
+// 处理函数中通过 c.MediaGroup() 获取按顺序排列的全部消息，c.Message 为其中第一条。
+// 被缓冲的消息不再分发到 Photo、Video、Document 等路由。
+// ListenWithContext 退出时会立即处理尚未到期的媒体组；其他方式接收更新时可以调用 FlushMediaGroups。
+//
+// 示例:
+//
+//	router.MediaGroup(func(c *Context) {
+//	    c.Reply(fmt.Sprintf("收到 %d 个文件", len(c.MediaGroup()))).Send()
+//	})
+func (g *RouterGroup) MediaGroup(handlers ...HandlerFunc) *RouteHandle {
+	return g.add("mediaGroup", &g.router.mediaGroupHandlers, handlers)
+}
+
+// MediaGroup 返回当前媒体组中按消息 ID 排序的全部消息，不是媒体组处理函数时返回 nil
+func (c *Context) MediaGroup() []*tgbotapi.Message {
+	return c.mediaGroup
+}
+
+// SetMediaGroupWindow 设置媒体组的缓冲时长，默认 1 秒。
+// 每收到同一媒体组的一条消息都会重新计时；d <= 0 时使用默认值。
+func (t *TelegramRouter) SetMediaGroupWindow(d time.Duration) *TelegramRouter {
+	if d <= 0 {
+		d = defaultMediaGroupWindow
+	}
+	t.mu.Lock()
+	t.mediaGroupWindow = d
+	t.mu.Unlock()
+	return t
+}
+
+// bufferMediaGroup 有匹配的媒体组路由时缓冲当前消息，返回是否已缓冲
+func (t *TelegramRouter) bufferMediaGroup(c *Context, rt *routeTable) bool {
+	msg := c.Message
+	if msg == nil || msg.MediaGroupID == "" || c.edited {
+		return false
+	}
+	matched := false
+	for _, h := range rt.mediaGroupHandlersC {
+		if h.scope.match(c) {
+			matched = true
+			break
+		}
+	}
+	if !matched {
+		return false
+	}
+
+	t.mu.RLock()
+	window := t.mediaGroupWindow
+	t.mu.RUnlock()
+
+	key := fmt.Sprintf("%d:%s", msg.Chat.ID, msg.MediaGroupID)
+	t.mediaGroupMu.Lock()
+	defer t.mediaGroupMu.Unlock()
+	buf, ok := t.mediaGroups[key]
+	if !ok {
+		buf = &mediaGroupBuffer{channel: c.channel}
+		buf.timer = time.AfterFunc(window, func() { t.flushMediaGroup(key, buf) })
+		t.mediaGroups[key] = buf
+	} else {
+		buf.timer.Reset(window)
+	}
+	buf.updates = append(buf.updates, c.Update)
+	return true
+}
+
+// flushMediaGroup 取出缓冲的媒体组并执行媒体组处理函数
+func (t *TelegramRouter) flushMediaGroup(key string, buf *mediaGroupBuffer) {
+	t.mediaGroupMu.Lock()
+	if t.mediaGroups[key] != buf {
+		// 已被 FlushMediaGroups 处理
+		t.mediaGroupMu.Unlock()
+		return
+	}
+	delete(t.mediaGroups, key)
+	buf.timer.Stop()
+	t.mediaGroupRunning++
+	t.mediaGroupMu.Unlock()
+	defer func() {
+		t.mediaGroupMu.Lock()
+		if t.mediaGroupRunning--; t.mediaGroupRunning == 0 {
+			t.mediaGroupIdle.Broadcast()
+		}
+		t.mediaGroupMu.Unlock()
+	}()
+
+	sort.SliceStable(buf.updates, func(i, j int) bool {
+		return buf.updates[i].Message.MessageID < buf.updates[j].Message.MessageID
+	})
+	messages := make([]*tgbotapi.Message, len(buf.updates))
+	for i, u := range buf.updates {
+		messages[i] = u.Message
+	}
+
+	c := t.newContext(context.Background(), buf.updates[0])
+	c.channel = buf.channel
+	c.mediaGroup = messages
+	defer t.saveSession(c)
+	t.run(c, t.snapshot().mediaGroupHandlersC)
+}
+
+// FlushMediaGroups 立即处理所有尚未到期的媒体组，并等待已到期、正在执行的媒体组处理完成后返回。
+// ListenWithContext 退出时会自动调用；使用 Webhook 时可以在关闭服务前调用，避免丢失相册。
+func (t *TelegramRouter) FlushMediaGroups() {
+	t.mediaGroupMu.Lock()
+	pending := make(map[string]*mediaGroupBuffer, len(t.mediaGroups))
+	for key, buf := range t.mediaGroups {
+		pending[key] = buf
+	}
+	t.mediaGroupMu.Unlock()
+	for key, buf := range pending {
+		t.flushMediaGroup(key, buf)
+	}
+	t.mediaGroupMu.Lock()
+	for t.mediaGroupRunning > 0 {
+		t.mediaGroupIdle.Wait()
+	}
+	t.mediaGroupMu.Unlock()
+}
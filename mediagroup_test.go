@@ -0,0 +1,194 @@
+package tgr
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// albumUpdate 构造相册中的一条图片消息
+func albumUpdate(groupID string, messageID int) *tgbotapi.Update {
+	return &tgbotapi.Update{Message: &tgbotapi.Message{
+		MessageID:    messageID,
+		MediaGroupID: groupID,
+		Photo:        []tgbotapi.PhotoSize{{FileID: fmt.Sprint("p", messageID)}},
+		Chat:         testChat(),
+		From:         &tgbotapi.User{ID: 7},
+	}}
+}
+
+// messageIDs 返回消息 ID 列表
+func messageIDs(messages []*tgbotapi.Message) []int {
+	ids := make([]int, len(messages))
+	for i, m := range messages {
+		ids[i] = m.MessageID
+	}
+	return ids
+}
+
+func TestMediaGroupAggregatesAlbum(t *testing.T) {
+	router := NewTelegramRouter(nil).SetMediaGroupWindow(20 * time.Millisecond)
+	calls := make(chan []int, 4)
+	router.MediaGroup(func(c *Context) {
+		if c.Message != c.MediaGroup()[0] {
+			t.Error("c.Message is not the first message of the group")
+		}
+		calls <- messageIDs(c.MediaGroup())
+	})
+	photos := 0
+	router.Photo(func(c *Context) { photos++ })
+
+	for _, id := range []int{13, 11, 15, 12, 14} {
+		router.HandleUpdate(albumUpdate("album", id))
+	}
+	router.HandleUpdate(albumUpdate("other", 20))
+
+	got := map[int][]int{}
+	for i := 0; i < 2; i++ {
+		select {
+		case ids := <-calls:
+			got[ids[0]] = ids
+		case <-time.After(time.Second):
+			t.Fatal("media group handler was not called")
+		}
+	}
+	want := map[int][]int{11: {11, 12, 13, 14, 15}, 20: {20}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("groups %v, want %v", got, want)
+	}
+	select {
+	case ids := <-calls:
+		t.Errorf("extra media group call %v", ids)
+	case <-time.After(50 * time.Millisecond):
+	}
+	if photos != 0 {
+		t.Errorf("photo route ran %d times for buffered messages", photos)
+	}
+}
+
+func TestMediaGroupFlushOnShutdown(t *testing.T) {
+	bot, api := newFakeBot()
+	// 相册的前两张已到达，第三张尚未到达时停止服务
+	api.queued["getUpdates"] = []string{`[` +
+		`{"update_id":1,"message":{"message_id":2,"media_group_id":"album","date":0,"chat":{"id":1,"type":"private"},"photo":[{"file_id":"b"}]}},` +
+		`{"update_id":2,"message":{"message_id":1,"media_group_id":"album","date":0,"chat":{"id":1,"type":"private"},"photo":[{"file_id":"a"}]}}` +
+		`]`}
+	router := NewTelegramRouter(bot).SetMediaGroupWindow(time.Hour)
+	router.Logger = nil
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var received sync.WaitGroup
+	received.Add(2)
+	router.OnUpdate(func(c *Context) { received.Done() })
+	var got [][]int
+	router.MediaGroup(func(c *Context) { got = append(got, messageIDs(c.MediaGroup())) })
+
+	done := make(chan struct{})
+	go func() {
+		router.ListenWithContext(ctx, 2, 0)
+		close(done)
+	}()
+	received.Wait()
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("ListenWithContext did not return")
+	}
+	if want := [][]int{{1, 2}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("media groups %v, want %v", got, want)
+	}
+}
+
+func TestFlushMediaGroupsConcurrentWithTimers(t *testing.T) {
+	router := NewTelegramRouter(nil).SetMediaGroupWindow(time.Millisecond)
+	var mu sync.Mutex
+	seen := map[string]int{}
+	router.MediaGroup(func(c *Context) {
+		time.Sleep(time.Millisecond)
+		mu.Lock()
+		seen[c.Message.MediaGroupID] += len(c.MediaGroup())
+		mu.Unlock()
+	})
+
+	// 相册计时器到期的同时反复调用 FlushMediaGroups
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+					router.FlushMediaGroups()
+				}
+			}
+		}()
+	}
+	for i := 0; i < 100; i++ {
+		group := fmt.Sprint("album", i)
+		router.HandleUpdate(albumUpdate(group, 1))
+		router.HandleUpdate(albumUpdate(group, 2))
+		time.Sleep(200 * time.Microsecond)
+	}
+	time.Sleep(5 * time.Millisecond)
+	close(stop)
+	wg.Wait()
+	router.FlushMediaGroups()
+	time.Sleep(10 * time.Millisecond)
+	router.FlushMediaGroups()
+
+	mu.Lock()
+	defer mu.Unlock()
+	total := 0
+	for _, n := range seen {
+		total += n
+	}
+	if len(seen) != 100 || total != 200 {
+		t.Errorf("handled %d groups with %d messages, want 100 and 200", len(seen), total)
+	}
+}
+
+func TestFlushMediaGroupsWaitsForRunningGroups(t *testing.T) {
+	router := NewTelegramRouter(nil).SetMediaGroupWindow(time.Millisecond)
+	running, release := make(chan struct{}), make(chan struct{})
+	finished := false
+	router.MediaGroup(func(c *Context) {
+		close(running)
+		<-release
+		finished = true
+	})
+	router.HandleUpdate(&tgbotapi.Update{Message: &tgbotapi.Message{
+		MessageID:    1,
+		MediaGroupID: "album",
+		Photo:        []tgbotapi.PhotoSize{{FileID: "p"}},
+		Chat:         testChat(),
+		From:         &tgbotapi.User{ID: 7},
+	}})
+	<-running // 窗口期已过，媒体组正在处理
+
+	done := make(chan struct{})
+	go func() {
+		router.FlushMediaGroups()
+		close(done)
+	}()
+	select {
+	case <-done:
+		t.Fatal("FlushMediaGroups returned while a media group was still running")
+	case <-time.After(50 * time.Millisecond):
+	}
+	close(release)
+	<-done
+	if !finished {
+		t.Error("media group handler did not finish")
+	}
+}
@@ -0,0 +1,39 @@
+package tgr
+
+// NoRoute 注册兜底处理函数，更新没有被任何路由处理时执行。
+// 通用更新处理器（OnUpdate）不算作匹配；处理链被中断时不再执行兜底处理函数。
+// 兜底处理函数同样应用全局及分组中间件，在状态分组中注册时只在对应状态下生效。
+//
+// 消息、回调查询、内联查询、成员变更等所有类型的更新未匹配时都会执行兜底处理函数，以下更新除外：
+// 通过 "/cmd@OtherBot" 指定给其他机器人的命令；未通过 SetRouteEditedMessages 或 Edited 开启分发的编辑后消息；
+// 编辑后的频道消息。频道消息只交给 Channel 分组中的兜底处理函数，可以按 Private、Groups 等分组分别注册。
+//
+// 示例:
+//
+//	router.NoRoute(func(c *Context) {
+//	    c.Reply("暂不支持该消息").Send()
+//	})
+func (g *RouterGroup) NoRoute(handlers ...HandlerFunc) *RouteHandle {
+	return g.add("noRoute", &g.router.noRouteHandlers, handlers)
+}
+
+// NoCommand 注册未知命令的处理函数，命令没有匹配任何命令路由时执行。
+// 注册后未匹配的命令不再交给文本处理函数。
+func (g *RouterGroup) NoCommand(handlers ...HandlerFunc) *RouteHandle {
+	return g.add("noCommand", &g.router.noCommandHandlers, handlers)
+}
+
+// NoCallback 注册未匹配回调的处理函数，回调数据没有匹配任何回调路由时执行，
+// 可以在其中回答回调查询，避免客户端一直显示加载状态。
+func (g *RouterGroup) NoCallback(handlers ...HandlerFunc) *RouteHandle {
+	return g.add("noCallback", &g.router.noCallbackHandlers, handlers)
+}
+
+// reachesNoRoute 判断未匹配的更新是否交给兜底处理函数
+func (c *Context) reachesNoRoute() bool {
+	if c.ignored {
+		return false
+	}
+	u := c.Update
+	return (u.EditedMessage == nil || c.edited) && u.EditedChannelPost == nil
+}
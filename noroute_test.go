@@ -0,0 +1,50 @@
+package tgr
+
+import (
+	"reflect"
+	"testing"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+func TestNoRouteSkipsCommandsForOtherBots(t *testing.T) {
+	router := NewTelegramRouter(&tgbotapi.BotAPI{Self: tgbotapi.User{UserName: "MyBot"}})
+	rec := &recorder{}
+	router.Command("start", rec.handler("start"))
+	router.NoRoute(rec.handler("noroute"))
+
+	for _, text := range []string{"/start@OtherBot", "/start@MyBot", "/unknown"} {
+		u := commandUpdate(text)
+		u.Message.Chat.Type = "group"
+		router.HandleUpdate(u)
+	}
+	if want := []string{"start", "noroute"}; !reflect.DeepEqual(rec.log, want) {
+		t.Errorf("ran %v, want %v", rec.log, want)
+	}
+}
+
+func TestNoRouteEditedMessages(t *testing.T) {
+	edit := func() *tgbotapi.Update {
+		return &tgbotapi.Update{EditedMessage: &tgbotapi.Message{Text: "typo", Chat: testChat(), From: &tgbotapi.User{ID: 7}}}
+	}
+	tests := []struct {
+		name   string
+		routed bool
+		want   []string
+	}{
+		{name: "edits not routed", want: nil},
+		{name: "edits routed", routed: true, want: []string{"noroute"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := NewTelegramRouter(nil).SetRouteEditedMessages(tt.routed)
+			rec := &recorder{}
+			router.NoRoute(rec.handler("noroute"))
+			router.HandleUpdate(edit())
+			router.HandleUpdate(&tgbotapi.Update{EditedChannelPost: &tgbotapi.Message{Text: "typo", Chat: &tgbotapi.Chat{ID: 2, Type: "channel"}}})
+			if !reflect.DeepEqual(rec.log, tt.want) {
+				t.Errorf("ran %v, want %v", rec.log, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,136 @@
+package tgr
+
+import tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+// MessageOptions 所有消息构建器共用的发送选项，嵌入在各构建器中，B 为所属构建器类型以保持链式调用。
+// 不提供 protect_content（禁止转发与保存）：tgbotapi v5.5.1 的 BaseChat 没有该字段，
+// 各消息配置的请求参数也无法在库外扩展，需要时请升级 tgbotapi 或直接调用 Bot.MakeRequest。
+//
+// 示例:
+//
+//	c.Reply("已静默处理").WithSilent().WithNoReply().Send()
+//	router.SendTo(chatID).Text("回复原消息").WithReplyTo(messageID).WithAllowSendingWithoutReply().Send()
+type MessageOptions[B any] struct {
+	self B
+	base *tgbotapi.BaseChat
+}
+
+// bind 将选项绑定到构建器及其消息配置
+func (o *MessageOptions[B]) bind(self B, base *tgbotapi.BaseChat) {
+	o.self = self
+	o.base = base
+}
+
+// WithSilent 静默发送，接收方不会收到通知声音
+func (o *MessageOptions[B]) WithSilent() B {
+	o.base.DisableNotification = true
+	return o.self
+}
+
+// WithReplyTo 回复指定的消息，而不是当前更新中的消息
+func (o *MessageOptions[B]) WithReplyTo(messageID int) B {
+	o.base.ReplyToMessageID = messageID
+	return o.self
+}
+
+// WithNoReply 不回复任何消息，作为普通消息发送
+func (o *MessageOptions[B]) WithNoReply() B {
+	o.base.ReplyToMessageID = 0
+	return o.self
+}
+
+// WithAllowSendingWithoutReply 被回复的消息已删除时仍然发送
+func (o *MessageOptions[B]) WithAllowSendingWithoutReply() B {
+	o.base.AllowSendingWithoutReply = true
+	return o.self
+}
+
+func newTextMessageBuilder(msg *tgbotapi.MessageConfig, bot *tgbotapi.BotAPI) *TextMessageBuilder {
+	b := &TextMessageBuilder{Msg: msg, bot: bot}
+	b.bind(b, &msg.BaseChat)
+	return b
+}
+
+func newPhotoMessageBuilder(msg *tgbotapi.PhotoConfig, bot *tgbotapi.BotAPI) *PhotoMessageBuilder {
+	b := &PhotoMessageBuilder{Msg: msg, bot: bot}
+	b.bind(b, &msg.BaseChat)
+	return b
+}
+
+func newPollMessageBuilder(msg *tgbotapi.SendPollConfig, bot *tgbotapi.BotAPI) *PollMessageBuilder {
+	b := &PollMessageBuilder{Msg: msg, bot: bot}
+	b.bind(b, &msg.BaseChat)
+	return b
+}
+
+func newMediaGroupBuilder(chatID int64, bot *tgbotapi.BotAPI) *MediaGroupBuilder {
+	b := &MediaGroupBuilder{ChatID: chatID, bot: bot}
+	b.bind(b, &b.base)
+	return b
+}
+
+func newInvoiceBuilder(msg *tgbotapi.InvoiceConfig, bot *tgbotapi.BotAPI) *InvoiceBuilder {
+	b := &InvoiceBuilder{Msg: msg, bot: bot}
+	b.bind(b, &msg.BaseChat)
+	return b
+}
+
+func newLocationMessageBuilder(msg *tgbotapi.LocationConfig, bot *tgbotapi.BotAPI) *LocationMessageBuilder {
+	b := &LocationMessageBuilder{Msg: msg, bot: bot}
+	b.bind(b, &msg.BaseChat)
+	return b
+}
+
+func newVenueMessageBuilder(msg *tgbotapi.VenueConfig, bot *tgbotapi.BotAPI) *VenueMessageBuilder {
+	b := &VenueMessageBuilder{Msg: msg, bot: bot}
+	b.bind(b, &msg.BaseChat)
+	return b
+}
+
+func newContactMessageBuilder(msg *tgbotapi.ContactConfig, bot *tgbotapi.BotAPI) *ContactMessageBuilder {
+	b := &ContactMessageBuilder{Msg: msg, bot: bot}
+	b.bind(b, &msg.BaseChat)
+	return b
+}
+
+func newDocumentMessageBuilder(msg *tgbotapi.DocumentConfig, bot *tgbotapi.BotAPI) *DocumentMessageBuilder {
+	b := &DocumentMessageBuilder{Msg: msg, bot: bot}
+	b.bind(b, &msg.BaseChat)
+	return b
+}
+
+func newAudioMessageBuilder(msg *tgbotapi.AudioConfig, bot *tgbotapi.BotAPI) *AudioMessageBuilder {
+	b := &AudioMessageBuilder{Msg: msg, bot: bot}
+	b.bind(b, &msg.BaseChat)
+	return b
+}
+
+func newVideoMessageBuilder(msg *tgbotapi.VideoConfig, bot *tgbotapi.BotAPI) *VideoMessageBuilder {
+	b := &VideoMessageBuilder{Msg: msg, bot: bot}
+	b.bind(b, &msg.BaseChat)
+	return b
+}
+
+func newVoiceMessageBuilder(msg *tgbotapi.VoiceConfig, bot *tgbotapi.BotAPI) *VoiceMessageBuilder {
+	b := &VoiceMessageBuilder{Msg: msg, bot: bot}
+	b.bind(b, &msg.BaseChat)
+	return b
+}
+
+func newVideoNoteMessageBuilder(msg *tgbotapi.VideoNoteConfig, bot *tgbotapi.BotAPI) *VideoNoteMessageBuilder {
+	b := &VideoNoteMessageBuilder{Msg: msg, bot: bot}
+	b.bind(b, &msg.BaseChat)
+	return b
+}
+
+func newStickerMessageBuilder(msg *tgbotapi.StickerConfig, bot *tgbotapi.BotAPI) *StickerMessageBuilder {
+	b := &StickerMessageBuilder{Msg: msg, bot: bot}
+	b.bind(b, &msg.BaseChat)
+	return b
+}
+
+func newAnimationMessageBuilder(msg *tgbotapi.AnimationConfig, bot *tgbotapi.BotAPI) *AnimationMessageBuilder {
+	b := &AnimationMessageBuilder{Msg: msg, bot: bot}
+	b.bind(b, &msg.BaseChat)
+	return b
+}
@@ -0,0 +1,31 @@
+package tgr
+
+import (
+	"testing"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+func TestMessageOptions(t *testing.T) {
+	c := &Context{Update: textUpdate("hi")}
+	c.Message.MessageID = 5
+
+	b := c.Reply("x").WithSilent().WithNoReply()
+	if got := b.Msg.BaseChat; !got.DisableNotification || got.ReplyToMessageID != 0 {
+		t.Errorf("WithSilent().WithNoReply() = %+v", got)
+	}
+	p := c.ReplyWithPhotoFileID("f").WithReplyTo(9).WithAllowSendingWithoutReply()
+	if got := p.Msg.BaseChat; got.ReplyToMessageID != 9 || !got.AllowSendingWithoutReply {
+		t.Errorf("WithReplyTo(9).WithAllowSendingWithoutReply() = %+v", got)
+	}
+}
+
+func TestMediaGroupRejectsAllowSendingWithoutReply(t *testing.T) {
+	b := newMediaGroupBuilder(1, nil).
+		Add(tgbotapi.NewInputMediaPhoto(tgbotapi.FileID("a"))).
+		Add(tgbotapi.NewInputMediaPhoto(tgbotapi.FileID("b"))).
+		WithAllowSendingWithoutReply()
+	if _, err := b.Send(); err == nil {
+		t.Fatal("Send() succeeded, want error for AllowSendingWithoutReply")
+	}
+}
@@ -0,0 +1,90 @@
+package tgr
+
+// DispatchPolicy 分发策略，决定一个路由执行完后是否继续执行同一类更新的其他匹配路由。
+//
+// 同一类更新的候选路由按固定顺序排列：带条件作用域（会话状态、聊天类型）的路由优先于普通路由，
+// 默认策略与 FirstMatch 下其中有路由执行时不再执行普通路由，AllMatch 与 FallThroughUntilAbort 下继续执行普通路由；
+// 命令依次为深度链接路由、命令路由、正则命令路由；回调依次为按优先级匹配的回调路由、通用回调处理器；
+// 位置范围路由（LocationInRange）与普通位置路由按注册顺序排列；轮询依次为条件轮询路由（PollWithType）、测验或普通投票路由、通用轮询路由；
+// 谓词路由（On）按注册顺序排列。
+// 一次注册的多个处理函数属于同一个路由。
+type DispatchPolicy uint8
+
+const (
+	// DispatchDefault 沿用各类更新原有的分发方式（未设置策略时的默认值）
+	DispatchDefault DispatchPolicy = iota
+	// FirstMatch 第一个匹配的路由执行后停止分发
+	FirstMatch
+	// AllMatch 执行所有匹配的路由，某个路由被中断只结束该路由自身的处理链
+	AllMatch
+	// FallThroughUntilAbort 依次执行匹配的路由，直到某个路由调用 c.Abort()
+	FallThroughUntilAbort
+)
+
+// String 返回策略名称
+func (p DispatchPolicy) String() string {
+	switch p {
+	case FirstMatch:
+		return "FirstMatch"
+	case AllMatch:
+		return "AllMatch"
+	case FallThroughUntilAbort:
+		return "FallThroughUntilAbort"
+	default:
+		return "Default"
+	}
+}
+
+// SetDispatchPolicy 设置路由器默认的分发策略，对所有类型的更新生效，
+// 可以通过 WithDispatchPolicy 为分组内的路由单独设置。
+//
+// 示例:
+//
+//	router.SetDispatchPolicy(tgr.FirstMatch)
+func (t *TelegramRouter) SetDispatchPolicy(policy DispatchPolicy) *TelegramRouter {
+	t.mu.Lock()
+	t.dispatchPolicy = policy
+	t.composedDirty = true
+	t.mu.Unlock()
+	return t
+}
+
+// WithDispatchPolicy 返回使用指定分发策略的路由分组，覆盖路由器默认的分发策略。
+//
+// 示例:
+//
+//	// 审计日志不影响后续文本路由
+//	router.WithDispatchPolicy(tgr.AllMatch).Text(auditHandler)
+func (g *RouterGroup) WithDispatchPolicy(policy DispatchPolicy) *RouterGroup {
+	return &RouterGroup{
+		router: g.router,
+		parent: g,
+		policy: policy,
+	}
+}
+
+// policyFor 返回分组生效的分发策略：最近一级设置的分组策略，否则为路由器默认策略。
+// 调用方需持有 t.mu。
+func (t *TelegramRouter) policyFor(g *RouterGroup) DispatchPolicy {
+	for ; g != nil; g = g.parent {
+		if g.policy != DispatchDefault {
+			return g.policy
+		}
+	}
+	return t.dispatchPolicy
+}
+
+// settle 在一个路由执行完后按分发策略决定是否停止分发，返回 true 表示停止。
+// FirstMatch 通过中断上下文结束后续分发；AllMatch 清除该路由自身的中断。
+func (t *TelegramRouter) settle(c *Context, policy DispatchPolicy) bool {
+	switch policy {
+	case FirstMatch:
+		c.Abort()
+		return true
+	case AllMatch:
+		c.aborted = false
+		return false
+	default:
+		return c.IsAborted()
+	}
+}
@@ -0,0 +1,250 @@
+package tgr
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+func locationUpdate(lat, lon float64) *tgbotapi.Update {
+	return &tgbotapi.Update{Message: &tgbotapi.Message{
+		Location: &tgbotapi.Location{Latitude: lat, Longitude: lon},
+		Chat:     testChat(),
+		From:     &tgbotapi.User{ID: 7},
+	}}
+}
+
+func pollUpdate(pollType string) *tgbotapi.Update {
+	return &tgbotapi.Update{Poll: &tgbotapi.Poll{ID: "p", Type: pollType}}
+}
+
+func (r *recorder) aborting(name string) HandlerFunc {
+	return func(c *Context) {
+		r.log = append(r.log, name)
+		c.Abort()
+	}
+}
+
+func TestDispatchPolicies(t *testing.T) {
+	type setup func(router *TelegramRouter, rec *recorder)
+
+	kinds := []struct {
+		name   string
+		setup  setup
+		update *tgbotapi.Update
+		state  string
+		// 各策略下期望的执行顺序
+		want map[DispatchPolicy][]string
+	}{
+		{
+			name: "command",
+			setup: func(router *TelegramRouter, rec *recorder) {
+				router.Command("go", rec.handler("a"))
+				router.Command("go", rec.handler("b"))
+			},
+			update: commandUpdate("/go"),
+			want: map[DispatchPolicy][]string{
+				DispatchDefault:       {"a", "b"},
+				FirstMatch:            {"a"},
+				AllMatch:              {"a", "b"},
+				FallThroughUntilAbort: {"a", "b"},
+			},
+		},
+		{
+			name: "text",
+			setup: func(router *TelegramRouter, rec *recorder) {
+				router.Text(rec.handler("a"))
+				router.Text(rec.handler("b"))
+			},
+			update: textUpdate("hi"),
+			want: map[DispatchPolicy][]string{
+				DispatchDefault:       {"a", "b"},
+				FirstMatch:            {"a"},
+				AllMatch:              {"a", "b"},
+				FallThroughUntilAbort: {"a", "b"},
+			},
+		},
+		{
+			name: "callback route vs wildcard",
+			setup: func(router *TelegramRouter, rec *recorder) {
+				router.Callback("item/*", rec.handler("wildcard"))
+				router.Callback("item/:id", rec.handler("param"))
+			},
+			update: callbackUpdate("item/1"),
+			want: map[DispatchPolicy][]string{
+				DispatchDefault:       {"param"},
+				FirstMatch:            {"param"},
+				AllMatch:              {"param", "wildcard"},
+				FallThroughUntilAbort: {"param", "wildcard"},
+			},
+		},
+		{
+			name: "location range vs plain",
+			setup: func(router *TelegramRouter, rec *recorder) {
+				router.LocationInRange(0, 10, 0, 10, rec.handler("range"))
+				router.LocationInRange(20, 30, 20, 30, rec.handler("elsewhere"))
+				router.Location(rec.handler("plain"))
+			},
+			update: locationUpdate(5, 5),
+			want: map[DispatchPolicy][]string{
+				DispatchDefault:       {"range", "plain"},
+				FirstMatch:            {"range"},
+				AllMatch:              {"range", "plain"},
+				FallThroughUntilAbort: {"range", "plain"},
+			},
+		},
+		{
+			name: "poll type",
+			setup: func(router *TelegramRouter, rec *recorder) {
+				router.PollWithType(PollType{Type: "quiz"}, rec.handler("a"))
+				router.PollWithType(PollType{}, rec.handler("b"))
+				router.PollWithType(PollType{Type: "quiz"}, rec.handler("c"))
+				router.Poll(rec.handler("poll"))
+			},
+			update: pollUpdate("quiz"),
+			want: map[DispatchPolicy][]string{
+				DispatchDefault:       {"a", "b", "c", "poll"},
+				FirstMatch:            {"a"},
+				AllMatch:              {"a", "b", "c", "poll"},
+				FallThroughUntilAbort: {"a", "b", "c", "poll"},
+			},
+		},
+		{
+			name: "state scoped vs plain",
+			setup: func(router *TelegramRouter, rec *recorder) {
+				router.Text(rec.handler("plain"))
+				router.State("s").Text(rec.handler("state"))
+			},
+			update: textUpdate("hi"),
+			state:  "s",
+			want: map[DispatchPolicy][]string{
+				DispatchDefault:       {"state"},
+				FirstMatch:            {"state"},
+				AllMatch:              {"state", "plain"},
+				FallThroughUntilAbort: {"state", "plain"},
+			},
+		},
+		{
+			name: "chat type scoped vs plain",
+			setup: func(router *TelegramRouter, rec *recorder) {
+				router.Command("go", rec.handler("plain"))
+				router.Private().Command("go", rec.handler("private"))
+			},
+			update: commandUpdate("/go"),
+			want: map[DispatchPolicy][]string{
+				DispatchDefault:       {"private"},
+				FirstMatch:            {"private"},
+				AllMatch:              {"private", "plain"},
+				FallThroughUntilAbort: {"private", "plain"},
+			},
+		},
+		{
+			name: "per-route override",
+			setup: func(router *TelegramRouter, rec *recorder) {
+				router.WithDispatchPolicy(FirstMatch).Text(rec.handler("first"))
+				router.Text(rec.handler("b"))
+			},
+			update: textUpdate("hi"),
+			want: map[DispatchPolicy][]string{
+				DispatchDefault:       {"first"},
+				FirstMatch:            {"first"},
+				AllMatch:              {"first"},
+				FallThroughUntilAbort: {"first"},
+			},
+		},
+		{
+			name: "abort",
+			setup: func(router *TelegramRouter, rec *recorder) {
+				router.Text(rec.aborting("a"))
+				router.Text(rec.handler("b"))
+			},
+			update: textUpdate("hi"),
+			want: map[DispatchPolicy][]string{
+				DispatchDefault:       {"a"},
+				FirstMatch:            {"a"},
+				AllMatch:              {"a", "b"},
+				FallThroughUntilAbort: {"a"},
+			},
+		},
+	}
+
+	policies := []DispatchPolicy{DispatchDefault, FirstMatch, AllMatch, FallThroughUntilAbort}
+	for _, kind := range kinds {
+		for _, policy := range policies {
+			t.Run(kind.name+"/"+policy.String(), func(t *testing.T) {
+				router := NewTelegramRouter(nil).SetDispatchPolicy(policy)
+				rec := &recorder{}
+				kind.setup(router, rec)
+				if kind.state != "" {
+					c := &Context{Update: kind.update}
+					key, err := c.stateKey()
+					if err != nil {
+						t.Fatal(err)
+					}
+					if err := router.stateStore.SetState(context.Background(), key, kind.state, 0); err != nil {
+						t.Fatal(err)
+					}
+				}
+				router.HandleUpdate(kind.update)
+				if want := kind.want[policy]; !reflect.DeepEqual(rec.log, want) {
+					t.Errorf("ran %v, want %v", rec.log, want)
+				}
+			})
+		}
+	}
+}
+
+func TestFallThroughUntilAbort(t *testing.T) {
+	tests := []struct {
+		name  string
+		setup func(router *TelegramRouter, rec *recorder)
+		want  []string
+	}{
+		{
+			name: "abort stops later routes",
+			setup: func(router *TelegramRouter, rec *recorder) {
+				router.Text(rec.handler("a"))
+				router.Text(rec.aborting("b"))
+				router.Text(rec.handler("c"))
+			},
+			want: []string{"a", "b"},
+		},
+		{
+			name: "abort in middleware skips the route and stops",
+			setup: func(router *TelegramRouter, rec *recorder) {
+				router.Group(rec.aborting("mw")).Text(rec.handler("a"))
+				router.Text(rec.handler("b"))
+			},
+			want: []string{"mw"},
+		},
+		{
+			name: "abort in scoped tier skips the plain tier",
+			setup: func(router *TelegramRouter, rec *recorder) {
+				router.Private().Text(rec.aborting("private"))
+				router.Text(rec.handler("plain"))
+			},
+			want: []string{"private"},
+		},
+		{
+			name: "abort skips the rest of the same route",
+			setup: func(router *TelegramRouter, rec *recorder) {
+				router.Text(rec.aborting("a1"), rec.handler("a2"))
+				router.Text(rec.handler("b"))
+			},
+			want: []string{"a1"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := NewTelegramRouter(nil).SetDispatchPolicy(FallThroughUntilAbort)
+			rec := &recorder{}
+			tt.setup(router, rec)
+			router.HandleUpdate(textUpdate("hi"))
+			if !reflect.DeepEqual(rec.log, tt.want) {
+				t.Errorf("ran %v, want %v", rec.log, tt.want)
+			}
+		})
+	}
+}
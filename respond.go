@@ -0,0 +1,75 @@
+package tgr
+
+import (
+	"errors"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+var (
+	// ErrNoChat 更新不属于任何聊天（如内联查询、投票答案）
+	ErrNoChat = errors.New("update has no chat")
+	// ErrNoSender 更新没有发送者（如投票状态更新）
+	ErrNoSender = errors.New("update has no sender")
+)
+
+// updateSender 返回更新的发送者，无法确定时返回 nil
+func updateSender(u *tgbotapi.Update) *tgbotapi.User {
+	if from := u.SentFrom(); from != nil {
+		return from
+	}
+	switch {
+	case u.ChannelPost != nil:
+		return u.ChannelPost.From
+	case u.EditedChannelPost != nil:
+		return u.EditedChannelPost.From
+	case u.PollAnswer != nil:
+		return &u.PollAnswer.User
+	case u.MyChatMember != nil:
+		return &u.MyChatMember.From
+	case u.ChatMember != nil:
+		return &u.ChatMember.From
+	case u.ChatJoinRequest != nil:
+		return &u.ChatJoinRequest.From
+	}
+	return nil
+}
+
+// Chat 返回当前更新所在的聊天，适用于所有类型的更新：
+// 消息、编辑后的消息、频道消息取其 Chat，回调查询取 CallbackQuery.Message.Chat，
+// 成员变更与入群申请取其 Chat。内联查询等不属于任何聊天的更新返回 ErrNoChat。
+func (c *Context) Chat() (*tgbotapi.Chat, error) {
+	if chat := updateChat(c.Update); chat != nil {
+		return chat, nil
+	}
+	return nil, ErrNoChat
+}
+
+// Sender 返回当前更新的发送者，适用于所有类型的更新，没有发送者时返回 ErrNoSender。
+// 以频道或群组身份发送的消息没有发送者，可通过 c.Message.SenderChat 获取。
+func (c *Context) Sender() (*tgbotapi.User, error) {
+	if from := updateSender(c.Update); from != nil {
+		return from, nil
+	}
+	return nil, ErrNoSender
+}
+
+// Respond 创建发送到当前更新所在聊天的文本消息构建器，不回复任何消息。
+// 与 Reply 不同，回调查询、编辑后的消息、成员变更等更新中同样可用；
+// 更新不属于任何聊天时，Send 返回 ErrNoChat。
+//
+// 示例:
+//
+//	router.Callback("order/:id/cancel", func(c *Context) {
+//	    _ = c.AnswerCallback(tgr.AnswerCallbackOptions{})
+//	    c.Respond("订单已取消").Send()
+//	})
+func (c *Context) Respond(text string) *TextMessageBuilder {
+	chat, err := c.Chat()
+	if err != nil {
+		b := newTextMessageBuilder(&tgbotapi.MessageConfig{Text: text}, c.Bot)
+		b.err = err
+		return b
+	}
+	return c.SendTo(chat.ID).Text(text)
+}
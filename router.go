@@ -4,6 +4,7 @@ package tgr
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"log"
@@ -15,6 +16,7 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
@@ -23,15 +25,25 @@ import (
 // NewTelegramRouter 创建一个新的 Telegram 路由器实例。
 // 参数 bot 是已初始化的 Telegram Bot API 实例。
 func NewTelegramRouter(bot *tgbotapi.BotAPI) *TelegramRouter {
-	return &TelegramRouter{
-		Bot:                   bot,
-		Logger:                log.New(os.Stdout, "tgr ", log.LstdFlags|log.Lshortfile),
-		errorReporter:         nil,
-		commandHandlers:       make(map[string][]HandlerFunc),
-		locationRangeHandlers: make(map[LocationRange][]HandlerFunc),
-		documentTypeHandlers:  make(map[FileType][]HandlerFunc),
-		pollTypeHandlers:      make(map[PollType][]HandlerFunc),
-	}
+	t := &TelegramRouter{
+		Bot:                    bot,
+		Logger:                 log.New(os.Stdout, "tgr ", log.LstdFlags|log.Lshortfile),
+		errorReporter:          nil,
+		commandHandlers:        make(map[string][]*route),
+		stateStore:             NewMemoryStateStore(),
+		stateTimeouts:          make(map[string]time.Duration),
+		sessionStore:           NewMemorySessionStore(),
+		sessionTTL:             defaultSessionTTL,
+		sessionKey:             SessionPerChatUser,
+		chatAdminCache:         newTTLMap(),
+		chatAdminCacheTTL:      defaultChatAdminCacheTTL,
+		chatAdminDeniedMessage: defaultChatAdminDeniedMessage,
+		mediaGroups:            make(map[string]*mediaGroupBuffer),
+		mediaGroupWindow:       defaultMediaGroupWindow,
+	}
+	t.RouterGroup.router = t
+	t.mediaGroupIdle = sync.NewCond(&t.mediaGroupMu)
+	return t
 }
 
 func NewTelegramRouterWithDefaultRecover(bot *tgbotapi.BotAPI) *TelegramRouter {
@@ -60,13 +72,25 @@ func Recover(ctx *Context) {
 type Context struct {
 	context.Context
 	*tgbotapi.Update
-	Bot      *tgbotapi.BotAPI
-	Logger   *log.Logger
-	index    int               // 当前执行的处理函数索引
-	handlers []HandlerFunc     // 处理函数链
-	aborted  bool              // 是否已中断执行
-	params   map[string]string // 路由参数
-	query    map[string]string // URL 查询参数
+	Bot        *tgbotapi.BotAPI
+	Logger     *log.Logger
+	index      int                 // 当前执行的处理函数索引
+	handlers   []HandlerFunc       // 处理函数链
+	aborted    bool                // 是否已中断执行
+	params     map[string]string   // 路由参数
+	query      map[string]string   // URL 查询参数
+	router     *TelegramRouter     // 所属路由器
+	state      *string             // 会话状态缓存，首次读取时从存储加载
+	session    *Session            // 会话数据，首次读取时从存储加载
+	mu         sync.RWMutex        // 保护 keys
+	keys       map[string]any      // 请求级键值存储，用于中间件向处理函数传递数据
+	matched    bool                // 是否有路由处理了当前更新
+	lastPolicy DispatchPolicy      // 最近执行的路由的分发策略
+	channel    bool                // 是否为按普通消息分发的频道消息
+	edited     bool                // 是否为按普通消息分发的编辑后消息
+	allEdits   bool                // 是否所有路由都接收编辑后的消息
+	ignored    bool                // 是否为应忽略的更新（如指定给其他机器人的命令），不执行兜底处理器
+	mediaGroup []*tgbotapi.Message // 媒体组处理函数收到的全部消息
 }
 
 // AnswerCallbackOptions 回答回调的可选参数
@@ -106,18 +130,32 @@ type PollType struct {
 	AllowMultiple bool   // 是否允许多选（仅 regular 类型有效）
 }
 
+// route 一次路由注册：路由类型、模式、处理函数及其所属的路由分组
+type route struct {
+	kind     string // 路由类型，如 "command"、"text"、"callback"
+	pattern  string // 路由模式（命令、回调模式、正则等），没有时为空
+	group    *RouterGroup
+	handlers []HandlerFunc
+	args     *commandArgs        // 命令参数声明，仅命令路由使用
+	match    func(*Context) bool // 额外的匹配条件（如 TextMatch 的前缀），为 nil 时总是匹配
+	order    int                 // 注册顺序
+	removed  bool                // 是否已通过 RouteHandle 移除
+}
+
 // CallbackRoute 回调路由节点
 type CallbackRoute struct {
-	pattern string         // 路由模式，如 "user/:id/profile"
-	handler HandlerFunc    // 处理函数
+	*route                 // 路由注册，pattern 为路由模式，如 "user/:id/profile"
+	handler HandlerFunc    // 组合中间件后的处理函数
+	scope   scope          // 组合后的路由作用域
 	params  []string       // 参数名列表，如 ["id"]
-	regex   *regexp.Regexp // 编译后的正则表达式
+	policy  DispatchPolicy // 组合后的分发策略
 }
 
 // CommandRegexRoute 正则命令路由
 type CommandRegexRoute struct {
+	*route
 	regex    *regexp.Regexp
-	handlers []HandlerFunc
+	composed []composedHandler
 }
 
 // WebhookConfig Webhook 配置
@@ -160,10 +198,7 @@ func (c *Context) Reply(text string) *TextMessageBuilder {
 	}
 	msg := tgbotapi.NewMessage(c.Message.Chat.ID, text)
 	msg.ReplyToMessageID = c.Message.MessageID
-	return &TextMessageBuilder{
-		Msg: &msg,
-		bot: c.Bot,
-	}
+	return newTextMessageBuilder(&msg, c.Bot)
 }
 
 // ReplyWithPhotoFileID 创建图片消息构建器（文件ID）
@@ -173,10 +208,7 @@ func (c *Context) ReplyWithPhotoFileID(fileID string) *PhotoMessageBuilder {
 	}
 	msg := tgbotapi.NewPhoto(c.Message.Chat.ID, tgbotapi.FileID(fileID))
 	msg.ReplyToMessageID = c.Message.MessageID
-	return &PhotoMessageBuilder{
-		Msg: &msg,
-		bot: c.Bot,
-	}
+	return newPhotoMessageBuilder(&msg, c.Bot)
 }
 
 // ReplyWithPhotoFileURL 创建图片消息构建器（URL）
@@ -186,10 +218,7 @@ func (c *Context) ReplyWithPhotoFileURL(url string) *PhotoMessageBuilder {
 	}
 	msg := tgbotapi.NewPhoto(c.Message.Chat.ID, tgbotapi.FileURL(url))
 	msg.ReplyToMessageID = c.Message.MessageID
-	return &PhotoMessageBuilder{
-		Msg: &msg,
-		bot: c.Bot,
-	}
+	return newPhotoMessageBuilder(&msg, c.Bot)
 }
 
 // ReplyWithPhotoFileBytes 创建图片消息构建器（字节数据）
@@ -202,10 +231,7 @@ func (c *Context) ReplyWithPhotoFileBytes(data []byte) *PhotoMessageBuilder {
 		Bytes: data,
 	})
 	msg.ReplyToMessageID = c.Message.MessageID
-	return &PhotoMessageBuilder{
-		Msg: &msg,
-		bot: c.Bot,
-	}
+	return newPhotoMessageBuilder(&msg, c.Bot)
 }
 
 // ReplyWithPhotoFilePath 创建图片消息构建器（文件路径）
@@ -215,10 +241,7 @@ func (c *Context) ReplyWithPhotoFilePath(path string) *PhotoMessageBuilder {
 	}
 	msg := tgbotapi.NewPhoto(c.Message.Chat.ID, tgbotapi.FilePath(path))
 	msg.ReplyToMessageID = c.Message.MessageID
-	return &PhotoMessageBuilder{
-		Msg: &msg,
-		bot: c.Bot,
-	}
+	return newPhotoMessageBuilder(&msg, c.Bot)
 }
 
 // ReplyWithPhotoFileReader 创建图片消息构建器（io.Reader）
@@ -231,10 +254,7 @@ func (c *Context) ReplyWithPhotoFileReader(reader io.Reader) *PhotoMessageBuilde
 		Reader: reader,
 	})
 	msg.ReplyToMessageID = c.Message.MessageID
-	return &PhotoMessageBuilder{
-		Msg: &msg,
-		bot: c.Bot,
-	}
+	return newPhotoMessageBuilder(&msg, c.Bot)
 }
 
 // ReplyWithDocumentFileID 通过文件ID发送文档
@@ -247,10 +267,7 @@ func (c *Context) ReplyWithDocumentFileID(fileID string, caption string) *Docume
 	if caption != "" {
 		msg.Caption = caption
 	}
-	return &DocumentMessageBuilder{
-		Msg: &msg,
-		bot: c.Bot,
-	}
+	return newDocumentMessageBuilder(&msg, c.Bot)
 }
 
 // ReplyWithDocumentFileURL 通过URL发送文档
@@ -263,10 +280,7 @@ func (c *Context) ReplyWithDocumentFileURL(url string, caption string) *Document
 	if caption != "" {
 		msg.Caption = caption
 	}
-	return &DocumentMessageBuilder{
-		Msg: &msg,
-		bot: c.Bot,
-	}
+	return newDocumentMessageBuilder(&msg, c.Bot)
 }
 
 // ReplyWithDocumentFileBytes 通过字节数据发送文档
@@ -282,10 +296,7 @@ func (c *Context) ReplyWithDocumentFileBytes(data []byte, caption string) *Docum
 	if caption != "" {
 		msg.Caption = caption
 	}
-	return &DocumentMessageBuilder{
-		Msg: &msg,
-		bot: c.Bot,
-	}
+	return newDocumentMessageBuilder(&msg, c.Bot)
 }
 
 // ReplyWithDocumentFilePath 通过文件路径发送文档
@@ -315,10 +326,7 @@ func (c *Context) ReplyWithDocumentFileReader(reader io.Reader, caption string)
 	if caption != "" {
 		msg.Caption = caption
 	}
-	return &DocumentMessageBuilder{
-		Msg: &msg,
-		bot: c.Bot,
-	}
+	return newDocumentMessageBuilder(&msg, c.Bot)
 }
 
 // ReplyWithAudioFileID 通过文件ID发送音频
@@ -331,10 +339,7 @@ func (c *Context) ReplyWithAudioFileID(fileID string, caption string) *AudioMess
 	if caption != "" {
 		msg.Caption = caption
 	}
-	return &AudioMessageBuilder{
-		Msg: &msg,
-		bot: c.Bot,
-	}
+	return newAudioMessageBuilder(&msg, c.Bot)
 }
 
 // ReplyWithAudioFileURL 通过URL发送音频
@@ -347,10 +352,7 @@ func (c *Context) ReplyWithAudioFileURL(url string, caption string) *AudioMessag
 	if caption != "" {
 		msg.Caption = caption
 	}
-	return &AudioMessageBuilder{
-		Msg: &msg,
-		bot: c.Bot,
-	}
+	return newAudioMessageBuilder(&msg, c.Bot)
 }
 
 // ReplyWithAudioFileBytes 通过字节数据发送音频
@@ -366,10 +368,7 @@ func (c *Context) ReplyWithAudioFileBytes(data []byte, caption string) *AudioMes
 	if caption != "" {
 		msg.Caption = caption
 	}
-	return &AudioMessageBuilder{
-		Msg: &msg,
-		bot: c.Bot,
-	}
+	return newAudioMessageBuilder(&msg, c.Bot)
 }
 
 // ReplyWithAudioFilePath 通过文件路径发送音频
@@ -382,10 +381,7 @@ func (c *Context) ReplyWithAudioFilePath(path string, caption string) *AudioMess
 	if caption != "" {
 		msg.Caption = caption
 	}
-	return &AudioMessageBuilder{
-		Msg: &msg,
-		bot: c.Bot,
-	}
+	return newAudioMessageBuilder(&msg, c.Bot)
 }
 
 // ReplyWithAudioFileReader 通过io.Reader发送音频
@@ -401,10 +397,7 @@ func (c *Context) ReplyWithAudioFileReader(reader io.Reader, caption string) *Au
 	if caption != "" {
 		msg.Caption = caption
 	}
-	return &AudioMessageBuilder{
-		Msg: &msg,
-		bot: c.Bot,
-	}
+	return newAudioMessageBuilder(&msg, c.Bot)
 }
 
 // ReplyWithVideoFileID 通过文件ID发送视频
@@ -417,10 +410,7 @@ func (c *Context) ReplyWithVideoFileID(fileID string, caption string) *VideoMess
 	if caption != "" {
 		msg.Caption = caption
 	}
-	return &VideoMessageBuilder{
-		Msg: &msg,
-		bot: c.Bot,
-	}
+	return newVideoMessageBuilder(&msg, c.Bot)
 }
 
 // ReplyWithVideoFileURL 通过URL发送视频
@@ -433,10 +423,7 @@ func (c *Context) ReplyWithVideoFileURL(url string, caption string) *VideoMessag
 	if caption != "" {
 		msg.Caption = caption
 	}
-	return &VideoMessageBuilder{
-		Msg: &msg,
-		bot: c.Bot,
-	}
+	return newVideoMessageBuilder(&msg, c.Bot)
 }
 
 // ReplyWithVideoFileBytes 通过字节数据发送视频
@@ -452,10 +439,7 @@ func (c *Context) ReplyWithVideoFileBytes(data []byte, caption string) *VideoMes
 	if caption != "" {
 		msg.Caption = caption
 	}
-	return &VideoMessageBuilder{
-		Msg: &msg,
-		bot: c.Bot,
-	}
+	return newVideoMessageBuilder(&msg, c.Bot)
 }
 
 // ReplyWithVideoFilePath 通过文件路径发送视频
@@ -468,10 +452,7 @@ func (c *Context) ReplyWithVideoFilePath(path string, caption string) *VideoMess
 	if caption != "" {
 		msg.Caption = caption
 	}
-	return &VideoMessageBuilder{
-		Msg: &msg,
-		bot: c.Bot,
-	}
+	return newVideoMessageBuilder(&msg, c.Bot)
 }
 
 // ReplyWithVideoFileReader 通过io.Reader发送视频
@@ -487,10 +468,7 @@ func (c *Context) ReplyWithVideoFileReader(reader io.Reader, caption string) *Vi
 	if caption != "" {
 		msg.Caption = caption
 	}
-	return &VideoMessageBuilder{
-		Msg: &msg,
-		bot: c.Bot,
-	}
+	return newVideoMessageBuilder(&msg, c.Bot)
 }
 
 // ReplyWithVoiceFileID 通过文件ID发送语音
@@ -500,10 +478,7 @@ func (c *Context) ReplyWithVoiceFileID(fileID string) *VoiceMessageBuilder {
 	}
 	msg := tgbotapi.NewVoice(c.Message.Chat.ID, tgbotapi.FileID(fileID))
 	msg.ReplyToMessageID = c.Message.MessageID
-	return &VoiceMessageBuilder{
-		Msg: &msg,
-		bot: c.Bot,
-	}
+	return newVoiceMessageBuilder(&msg, c.Bot)
 }
 
 // ReplyWithVoiceFileURL 通过URL发送语音
@@ -513,10 +488,7 @@ func (c *Context) ReplyWithVoiceFileURL(url string) *VoiceMessageBuilder {
 	}
 	msg := tgbotapi.NewVoice(c.Message.Chat.ID, tgbotapi.FileURL(url))
 	msg.ReplyToMessageID = c.Message.MessageID
-	return &VoiceMessageBuilder{
-		Msg: &msg,
-		bot: c.Bot,
-	}
+	return newVoiceMessageBuilder(&msg, c.Bot)
 }
 
 // ReplyWithVoiceFileBytes 通过字节数据发送语音
@@ -529,10 +501,7 @@ func (c *Context) ReplyWithVoiceFileBytes(data []byte) *VoiceMessageBuilder {
 		Bytes: data,
 	})
 	msg.ReplyToMessageID = c.Message.MessageID
-	return &VoiceMessageBuilder{
-		Msg: &msg,
-		bot: c.Bot,
-	}
+	return newVoiceMessageBuilder(&msg, c.Bot)
 }
 
 // ReplyWithVoiceFilePath 通过文件路径发送语音
@@ -542,10 +511,7 @@ func (c *Context) ReplyWithVoiceFilePath(path string) *VoiceMessageBuilder {
 	}
 	msg := tgbotapi.NewVoice(c.Message.Chat.ID, tgbotapi.FilePath(path))
 	msg.ReplyToMessageID = c.Message.MessageID
-	return &VoiceMessageBuilder{
-		Msg: &msg,
-		bot: c.Bot,
-	}
+	return newVoiceMessageBuilder(&msg, c.Bot)
 }
 
 // ReplyWithVoiceFileReader 通过io.Reader发送语音
@@ -558,10 +524,7 @@ func (c *Context) ReplyWithVoiceFileReader(reader io.Reader) *VoiceMessageBuilde
 		Reader: reader,
 	})
 	msg.ReplyToMessageID = c.Message.MessageID
-	return &VoiceMessageBuilder{
-		Msg: &msg,
-		bot: c.Bot,
-	}
+	return newVoiceMessageBuilder(&msg, c.Bot)
 }
 
 // ReplyWithVideoNoteFileID 通过文件ID发送视频笔记
@@ -822,6 +785,116 @@ func (c *Context) IsAborted() bool {
 	return c.aborted
 }
 
+// Set 在当前更新的上下文中保存键值，供后续中间件与处理函数读取。
+//
+// 示例:
+//
+//	router.Use(func(c *Context) {
+//	    c.Set("user", loadUser(c.SentFrom().ID))
+//	    c.Next()
+//	})
+func (c *Context) Set(key string, value any) {
+	c.mu.Lock()
+	if c.keys == nil {
+		c.keys = make(map[string]any)
+	}
+	c.keys[key] = value
+	c.mu.Unlock()
+}
+
+// Get 获取通过 Set 保存的值，exists 表示键是否存在
+func (c *Context) Get(key string) (value any, exists bool) {
+	c.mu.RLock()
+	value, exists = c.keys[key]
+	c.mu.RUnlock()
+	return
+}
+
+// MustGet 获取通过 Set 保存的值，键不存在时 panic
+func (c *Context) MustGet(key string) any {
+	if value, exists := c.Get(key); exists {
+		return value
+	}
+	panic("key \"" + key + "\" does not exist")
+}
+
+// GetString 获取字符串值，不存在或类型不符时返回空字符串
+func (c *Context) GetString(key string) (s string) {
+	if val, ok := c.Get(key); ok && val != nil {
+		s, _ = val.(string)
+	}
+	return
+}
+
+// GetInt 获取 int 值，不存在或类型不符时返回 0
+func (c *Context) GetInt(key string) (i int) {
+	if val, ok := c.Get(key); ok && val != nil {
+		i, _ = val.(int)
+	}
+	return
+}
+
+// GetInt64 获取 int64 值，不存在或类型不符时返回 0
+func (c *Context) GetInt64(key string) (i int64) {
+	if val, ok := c.Get(key); ok && val != nil {
+		i, _ = val.(int64)
+	}
+	return
+}
+
+// GetFloat64 获取 float64 值，不存在或类型不符时返回 0
+func (c *Context) GetFloat64(key string) (f float64) {
+	if val, ok := c.Get(key); ok && val != nil {
+		f, _ = val.(float64)
+	}
+	return
+}
+
+// GetBool 获取布尔值，不存在或类型不符时返回 false
+func (c *Context) GetBool(key string) (b bool) {
+	if val, ok := c.Get(key); ok && val != nil {
+		b, _ = val.(bool)
+	}
+	return
+}
+
+// GetTime 获取 time.Time 值，不存在或类型不符时返回零值
+func (c *Context) GetTime(key string) (t time.Time) {
+	if val, ok := c.Get(key); ok && val != nil {
+		t, _ = val.(time.Time)
+	}
+	return
+}
+
+// GetDuration 获取 time.Duration 值，不存在或类型不符时返回 0
+func (c *Context) GetDuration(key string) (d time.Duration) {
+	if val, ok := c.Get(key); ok && val != nil {
+		d, _ = val.(time.Duration)
+	}
+	return
+}
+
+// GetStringSlice 获取 []string 值，不存在或类型不符时返回 nil
+func (c *Context) GetStringSlice(key string) (ss []string) {
+	if val, ok := c.Get(key); ok && val != nil {
+		ss, _ = val.([]string)
+	}
+	return
+}
+
+// Value 实现 context.Context，字符串键优先返回通过 Set 保存的值
+func (c *Context) Value(key any) any {
+	if k, ok := key.(string); ok {
+		if val, exists := c.Get(k); exists {
+			return val
+		}
+	}
+	if c.Context == nil {
+		return nil
+	}
+	return c.Context.Value(key)
+}
+
 // Param 获取路由参数的值。
 // 如果参数不存在，返回空字符串。
 func (c *Context) Param(key string) string {
@@ -916,6 +989,8 @@ func parseQuery(query string) map[string]string {
 // TelegramRouter 是 Telegram 机器人的路由器。
 // 负责注册和管理各种消息类型的处理函数，以及中间件。
 type TelegramRouter struct {
+	// 根路由分组，提供全部注册方法
+	RouterGroup
 	Bot *tgbotapi.BotAPI
 	// 可插拔日志器
 	Logger *log.Logger
@@ -926,120 +1001,173 @@ type TelegramRouter struct {
 	// 全局中间件，按注册顺序执行
 	middlewares []HandlerFunc
 	// 文本消息处理器
-	textHandlers []HandlerFunc
+	textHandlers []*route
 	// 命令处理器
-	commandHandlers map[string][]HandlerFunc
+	commandHandlers map[string][]*route
 	// 正则命令处理器
 	commandRegexRoutes []*CommandRegexRoute
+	// /start 深度链接路由
+	startPayloadRoutes []*StartPayloadRoute
+	// 谓词路由
+	filterRoutes []*FilterRoute
+	// 启动时是否校验路由（Validate），发现问题时 panic
+	strictRoutes bool
+	// 按注册顺序登记的所有路由及注册计数，用于 Routes
+	registry   []*route
+	routeOrder int
+	// 会话状态存储及各状态的超时时间
+	stateStore    StateStore
+	stateTimeouts map[string]time.Duration
+	// 命令参数解析失败时的处理函数
+	commandUsageHandler CommandUsageHandler
+	// 会话存储、会话保存时长与会话键
+	sessionStore SessionStore
+	sessionTTL   time.Duration
+	sessionKey   SessionKeyFunc
+	// 同一进程内串行执行同一会话的处理链
+	sessionLocks keyLocks
+	// RequireChatAdmin 的成员信息缓存、缓存时长与拒绝时回复的内容
+	chatAdminCache         *ttlMap
+	chatAdminCacheTTL      time.Duration
+	chatAdminDeniedMessage string
 	// 文档消息处理器
-	documentHandlers []HandlerFunc
+	documentHandlers []*route
 	// 音频消息处理器
-	audioHandlers []HandlerFunc
+	audioHandlers []*route
 	// 视频消息处理器
-	videoHandlers []HandlerFunc
+	videoHandlers []*route
 	// 照片消息处理器
-	photoHandlers []HandlerFunc
+	photoHandlers []*route
 	// 贴纸消息处理器
-	stickerHandlers []HandlerFunc
+	stickerHandlers []*route
+	// 媒体组处理器，缓冲中的媒体组与缓冲时长，以及正在执行的媒体组处理
+	mediaGroupHandlers []*route
+	mediaGroupMu       sync.Mutex
+	mediaGroups        map[string]*mediaGroupBuffer
+	mediaGroupWindow   time.Duration
+	mediaGroupRunning  int        // 正在执行的媒体组数量，由 mediaGroupMu 保护
+	mediaGroupIdle     *sync.Cond // mediaGroupRunning 归零时广播
 	// 回调查询处理器
-	callbackHandlers []HandlerFunc
+	callbackHandlers []*route
+	// 默认分发策略
+	dispatchPolicy DispatchPolicy
+	// 编辑后的消息是否交给所有路由处理，以及是否有分组单独开启
+	routeEdits bool
+	editRoutes bool
+	// 兜底处理器：未匹配任何路由、未知命令、未匹配的回调
+	noRouteHandlers    []*route
+	noCommandHandlers  []*route
+	noCallbackHandlers []*route
 	// 位置消息处理器
-	locationHandlers []HandlerFunc
+	locationHandlers []*route
 	// 联系信息处理器
-	contactHandlers []HandlerFunc
+	contactHandlers []*route
 	// 轮询处理器
-	pollHandlers []HandlerFunc
-	// 轮询处理器（按类型匹配）
-	pollTypeHandlers map[PollType][]HandlerFunc
+	pollHandlers []*route
+	// 轮询处理器（按类型匹配，按注册顺序排列）
+	pollTypeHandlers []*route
 	// 测验处理器（quiz 类型的轮询）
-	quizHandlers []HandlerFunc
+	quizHandlers []*route
 	// 普通投票处理器（regular 类型的轮询）
-	regularPollHandlers []HandlerFunc
+	regularPollHandlers []*route
 	// 游戏处理器
-	gameHandlers []HandlerFunc
+	gameHandlers []*route
 	// 语音消息处理器
-	voiceHandlers []HandlerFunc
+	voiceHandlers []*route
 	// 视频笔记处理器
-	videoNoteHandlers []HandlerFunc
+	videoNoteHandlers []*route
 	// 动画处理器
-	animationHandlers []HandlerFunc
+	animationHandlers []*route
 	// 位置共享处理器
-	liveLocationHandlers []HandlerFunc
+	liveLocationHandlers []*route
 	// 群组/频道消息处理器
-	channelPostHandlers []HandlerFunc
-	// 位置消息处理器（带范围匹配）
-	locationRangeHandlers map[LocationRange][]HandlerFunc
-	// 文档处理器（带类型匹配）
-	documentTypeHandlers map[FileType][]HandlerFunc
+	channelPostHandlers []*route
 	// Inline 模式
-	inlineQueryHandlers        []HandlerFunc
-	chosenInlineResultHandlers []HandlerFunc
+	inlineQueryHandlers        []*route
+	chosenInlineResultHandlers []*route
 	// 回调路由处理器
 	callbackRoutes []*CallbackRoute
+	// 回调路由是否贯穿匹配（默认只执行优先级最高的路由）
+	callbackFallThrough bool
+	// 超长回调数据的服务端存储及保存时长
+	callbackStore CallbackStore
+	callbackTTL   time.Duration
 	// 群组相关处理器（支持多注册）
-	groupChatCreatedHandlers      []HandlerFunc
-	supergroupChatCreatedHandlers []HandlerFunc
-	channelChatCreatedHandlers    []HandlerFunc
-	newChatMembersHandlers        []HandlerFunc
-	leftChatMemberHandlers        []HandlerFunc
-	newChatTitleHandlers          []HandlerFunc
-	newChatPhotoHandlers          []HandlerFunc
-	deleteChatPhotoHandlers       []HandlerFunc
-	editedMessageHandlers         []HandlerFunc
-	editedChannelPostHandlers     []HandlerFunc
-	myChatMemberHandlers          []HandlerFunc
-	chatMemberHandlers            []HandlerFunc
-	pollAnswerHandlers            []HandlerFunc
-	preCheckoutQueryHandlers      []HandlerFunc
-	shippingQueryHandlers         []HandlerFunc
-	successfulPaymentHandlers     []HandlerFunc
+	groupChatCreatedHandlers      []*route
+	supergroupChatCreatedHandlers []*route
+	channelChatCreatedHandlers    []*route
+	newChatMembersHandlers        []*route
+	leftChatMemberHandlers        []*route
+	newChatTitleHandlers          []*route
+	newChatPhotoHandlers          []*route
+	deleteChatPhotoHandlers       []*route
+	editedMessageHandlers         []*route
+	editedChannelPostHandlers     []*route
+	myChatMemberHandlers          []*route
+	chatMemberHandlers            []*route
+	pollAnswerHandlers            []*route
+	preCheckoutQueryHandlers      []*route
+	shippingQueryHandlers         []*route
+	successfulPaymentHandlers     []*route
 	// 重命名为 updateHandlers
-	updateHandlers []HandlerFunc
-
-	// --- 组合后缓存，避免分发时重复包装中间件 ---
-	composedDirty                  bool
-	textHandlersC                  []HandlerFunc
-	documentHandlersC              []HandlerFunc
-	audioHandlersC                 []HandlerFunc
-	videoHandlersC                 []HandlerFunc
-	photoHandlersC                 []HandlerFunc
-	stickerHandlersC               []HandlerFunc
-	callbackHandlersC              []HandlerFunc
-	locationHandlersC              []HandlerFunc
-	contactHandlersC               []HandlerFunc
-	pollHandlersC                  []HandlerFunc
-	pollTypeHandlersC              map[PollType][]HandlerFunc
-	quizHandlersC                  []HandlerFunc
-	regularPollHandlersC           []HandlerFunc
-	gameHandlersC                  []HandlerFunc
-	voiceHandlersC                 []HandlerFunc
-	videoNoteHandlersC             []HandlerFunc
-	animationHandlersC             []HandlerFunc
-	liveLocationHandlersC          []HandlerFunc
-	channelPostHandlersC           []HandlerFunc
-	locationRangeHandlersC         map[LocationRange][]HandlerFunc
-	documentTypeHandlersC          map[FileType][]HandlerFunc
-	callbackRoutesC                []*CallbackRoute
-	commandHandlersC               map[string][]HandlerFunc
+	updateHandlers []*route
+
+	// --- 组合后的路由表快照，避免分发时重复包装中间件 ---
+	composedDirty bool
+	composed      atomic.Pointer[routeTable]
+}
+
+// routeTable 组合中间件后的路由表。
+// 每次注册变化后整体重建并原子替换，分发中的更新继续使用开始时取得的快照。
+type routeTable struct {
+	updateHandlersC                []composedHandler
+	textHandlersC                  []composedHandler
+	documentHandlersC              []composedHandler
+	audioHandlersC                 []composedHandler
+	videoHandlersC                 []composedHandler
+	photoHandlersC                 []composedHandler
+	stickerHandlersC               []composedHandler
+	mediaGroupHandlersC            []composedHandler
+	callbackHandlersC              []composedHandler
+	noRouteHandlersC               []composedHandler
+	noCommandHandlersC             []composedHandler
+	noCallbackHandlersC            []composedHandler
+	locationHandlersC              []composedHandler
+	contactHandlersC               []composedHandler
+	pollHandlersC                  []composedHandler
+	pollTypeHandlersC              []composedHandler
+	quizHandlersC                  []composedHandler
+	regularPollHandlersC           []composedHandler
+	gameHandlersC                  []composedHandler
+	voiceHandlersC                 []composedHandler
+	videoNoteHandlersC             []composedHandler
+	animationHandlersC             []composedHandler
+	liveLocationHandlersC          []composedHandler
+	channelPostHandlersC           []composedHandler
+	callbackTrieC                  *callbackNode
+	callbackConflictsC             [][2]*CallbackRoute
+	commandHandlersC               map[string][]composedHandler
 	commandRegexRoutesC            []*CommandRegexRoute
-	inlineQueryHandlersC           []HandlerFunc
-	chosenInlineResultHandlersC    []HandlerFunc
-	groupChatCreatedHandlersC      []HandlerFunc
-	supergroupChatCreatedHandlersC []HandlerFunc
-	channelChatCreatedHandlersC    []HandlerFunc
-	newChatMembersHandlersC        []HandlerFunc
-	leftChatMemberHandlersC        []HandlerFunc
-	newChatTitleHandlersC          []HandlerFunc
-	newChatPhotoHandlersC          []HandlerFunc
-	deleteChatPhotoHandlersC       []HandlerFunc
-	editedMessageHandlersC         []HandlerFunc
-	editedChannelPostHandlersC     []HandlerFunc
-	myChatMemberHandlersC          []HandlerFunc
-	chatMemberHandlersC            []HandlerFunc
-	pollAnswerHandlersC            []HandlerFunc
-	preCheckoutQueryHandlersC      []HandlerFunc
-	shippingQueryHandlersC         []HandlerFunc
-	successfulPaymentHandlersC     []HandlerFunc
+	startPayloadRoutesC            []*StartPayloadRoute
+	filterRoutesC                  []*FilterRoute
+	inlineQueryHandlersC           []composedHandler
+	chosenInlineResultHandlersC    []composedHandler
+	groupChatCreatedHandlersC      []composedHandler
+	supergroupChatCreatedHandlersC []composedHandler
+	channelChatCreatedHandlersC    []composedHandler
+	newChatMembersHandlersC        []composedHandler
+	leftChatMemberHandlersC        []composedHandler
+	newChatTitleHandlersC          []composedHandler
+	newChatPhotoHandlersC          []composedHandler
+	deleteChatPhotoHandlersC       []composedHandler
+	editedMessageHandlersC         []composedHandler
+	editedChannelPostHandlersC     []composedHandler
+	myChatMemberHandlersC          []composedHandler
+	chatMemberHandlersC            []composedHandler
+	pollAnswerHandlersC            []composedHandler
+	preCheckoutQueryHandlersC      []composedHandler
+	shippingQueryHandlersC         []composedHandler
+	successfulPaymentHandlersC     []composedHandler
 }
 
 // Use 添加全局中间件，支持链式调用。
@@ -1076,11 +1204,30 @@ func (t *TelegramRouter) Use(middlewares ...HandlerFunc) *TelegramRouter {
 //	router.Command("start", func(c *Context) {
 //	    c.Reply("欢迎使用机器人！").Send()
 //	})
-func (t *TelegramRouter) Command(command string, handlers ...HandlerFunc) {
+//
+// The command may declare arguments after its name: ":name" is required, ":name?" is optional,
+// ":name:type" validates the type (string, int, float, bool, duration) and a trailing
+// ":name..." captures the rest of the text. Quoted arguments ("two words") are supported.
+// Parsed values are available via c.Param; on failure the bot replies with a usage line.
+//
+// 命令名之后可以声明参数：":name" 必填，":name?" 可选，":name:type" 校验类型
+// （string、int、float、bool、duration），末尾的 ":name..." 匹配剩余全部文本，支持引号包裹的参数。
+// 解析结果通过 c.Param 获取；解析失败时自动回复根据模式生成的用法说明（见 SetCommandUsageHandler）。
+//
+//	router.Command("ban :user_id:int :duration?:duration :reason?...", func(c *Context) {
+//	    userID := c.Param("user_id")
+//	    reason := c.Param("reason")
+//	})
+func (g *RouterGroup) Command(command string, handlers ...HandlerFunc) *RouteHandle {
+	pattern := command
+	command, args := parseCommandPattern(command)
+	t := g.router
 	t.mu.Lock()
-	t.commandHandlers[command] = append(t.commandHandlers[command], handlers...)
+	defer t.mu.Unlock()
+	r := &route{kind: "command", pattern: pattern, group: g, handlers: handlers, args: args}
+	t.commandHandlers[command] = append(t.commandHandlers[command], r)
 	t.composedDirty = true
-	t.mu.Unlock()
+	return t.register(r, removeKeyFunc(t.commandHandlers, command, r))
 }
 
 // Text registers handlers for text messages.
@@ -1096,11 +1243,8 @@ func (t *TelegramRouter) Command(command string, handlers ...HandlerFunc) {
 //	router.Text(func(c *Context) {
 //	    c.Reply("收到文本消息：" + c.Message.Text).Send()
 //	})
-func (t *TelegramRouter) Text(handlers ...HandlerFunc) {
-	t.mu.Lock()
-	t.textHandlers = append(t.textHandlers, handlers...)
-	t.composedDirty = true
-	t.mu.Unlock()
+func (g *RouterGroup) Text(handlers ...HandlerFunc) *RouteHandle {
+	return g.add("text", &g.router.textHandlers, handlers)
 }
 
 // Document registers handlers for document messages.
@@ -1116,11 +1260,8 @@ func (t *TelegramRouter) Text(handlers ...HandlerFunc) {
 //	router.Document(func(c *Context) {
 //	    c.Reply("收到文档：" + c.Message.Document.FileName).Send()
 //	})
-func (t *TelegramRouter) Document(handlers ...HandlerFunc) {
-	t.mu.Lock()
-	t.documentHandlers = append(t.documentHandlers, handlers...)
-	t.composedDirty = true
-	t.mu.Unlock()
+func (g *RouterGroup) Document(handlers ...HandlerFunc) *RouteHandle {
+	return g.add("document", &g.router.documentHandlers, handlers)
 }
 
 // Audio registers handlers for audio messages.
@@ -1136,11 +1277,8 @@ func (t *TelegramRouter) Document(handlers ...HandlerFunc) {
 //	router.Audio(func(c *Context) {
 //	    c.Reply("收到音频文件").Send()
 //	})
-func (t *TelegramRouter) Audio(handlers ...HandlerFunc) {
-	t.mu.Lock()
-	t.audioHandlers = append(t.audioHandlers, handlers...)
-	t.composedDirty = true
-	t.mu.Unlock()
+func (g *RouterGroup) Audio(handlers ...HandlerFunc) *RouteHandle {
+	return g.add("audio", &g.router.audioHandlers, handlers)
 }
 
 // Video registers handlers for video messages.
@@ -1156,11 +1294,8 @@ func (t *TelegramRouter) Audio(handlers ...HandlerFunc) {
 //	router.Video(func(c *Context) {
 //	    c.Reply("收到视频文件").Send()
 //	})
-func (t *TelegramRouter) Video(handlers ...HandlerFunc) {
-	t.mu.Lock()
-	t.videoHandlers = append(t.videoHandlers, handlers...)
-	t.composedDirty = true
-	t.mu.Unlock()
+func (g *RouterGroup) Video(handlers ...HandlerFunc) *RouteHandle {
+	return g.add("video", &g.router.videoHandlers, handlers)
 }
 
 // Photo registers handlers for photo messages.
@@ -1176,11 +1311,8 @@ func (t *TelegramRouter) Video(handlers ...HandlerFunc) {
 //	router.Photo(func(c *Context) {
 //	    c.Reply("收到图片消息").Send()
 //	})
-func (t *TelegramRouter) Photo(handlers ...HandlerFunc) {
-	t.mu.Lock()
-	t.photoHandlers = append(t.photoHandlers, handlers...)
-	t.composedDirty = true
-	t.mu.Unlock()
+func (g *RouterGroup) Photo(handlers ...HandlerFunc) *RouteHandle {
+	return g.add("photo", &g.router.photoHandlers, handlers)
 }
 
 // Sticker registers handlers for sticker messages.
@@ -1196,29 +1328,24 @@ func (t *TelegramRouter) Photo(handlers ...HandlerFunc) {
 //	router.Sticker(func(c *Context) {
 //	    c.Reply("收到贴纸").Send()
 //	})
-func (t *TelegramRouter) Sticker(handlers ...HandlerFunc) {
-	t.mu.Lock()
-	t.stickerHandlers = append(t.stickerHandlers, handlers...)
-	t.composedDirty = true
-	t.mu.Unlock()
+func (g *RouterGroup) Sticker(handlers ...HandlerFunc) *RouteHandle {
+	return g.add("sticker", &g.router.stickerHandlers, handlers)
 }
 
 // Callback 注册回调查询处理函数。
 // 可以一次注册多个处理函数，它们会按顺序执行，直到被中断。
-func (t *TelegramRouter) Callback(pattern string, handlers ...HandlerFunc) {
+//
+// 模式按 "/" 分段，支持静态段（"menu"）、参数段（":id"）与通配符（"*"，匹配一个或多个段）。
+// 多个路由都能匹配时，静态段优先于参数段，参数段优先于通配符，
+// 默认只执行优先级最高的一个路由，可通过 SetCallbackFallThrough 开启贯穿匹配。
+func (g *RouterGroup) Callback(pattern string, handlers ...HandlerFunc) *RouteHandle {
+	t := g.router
 	t.mu.Lock()
-	t.callbackRoutes = append(t.callbackRoutes, &CallbackRoute{
-		pattern: pattern,
-		handler: func(c *Context) {
-			c.handlers = handlers
-			c.index = -1
-			c.Next()
-		},
-		params: parseRouteParams(pattern),
-		regex:  compileRoutePattern(pattern),
-	})
+	defer t.mu.Unlock()
+	r := &route{kind: "callback", pattern: pattern, group: g, handlers: handlers}
+	t.callbackRoutes = append(t.callbackRoutes, &CallbackRoute{route: r, params: parseRouteParams(pattern)})
 	t.composedDirty = true
-	t.mu.Unlock()
+	return t.register(r, removeFunc(&t.callbackRoutes, func(cr *CallbackRoute) bool { return cr.route == r }))
 }
 
 // Location registers handlers for location messages.
@@ -1235,11 +1362,8 @@ func (t *TelegramRouter) Callback(pattern string, handlers ...HandlerFunc) {
 //	    loc := c.Message.Location
 //	    c.Reply(fmt.Sprintf("收到位置：%.6f, %.6f", loc.Latitude, loc.Longitude)).Send()
 //	})
-func (t *TelegramRouter) Location(handlers ...HandlerFunc) {
-	t.mu.Lock()
-	t.locationHandlers = append(t.locationHandlers, handlers...)
-	t.composedDirty = true
-	t.mu.Unlock()
+func (g *RouterGroup) Location(handlers ...HandlerFunc) *RouteHandle {
+	return g.add("location", &g.router.locationHandlers, handlers)
 }
 
 // Contact registers handlers for contact messages.
@@ -1256,37 +1380,33 @@ func (t *TelegramRouter) Location(handlers ...HandlerFunc) {
 //	    contact := c.Message.Contact
 //	    c.Reply("收到联系人：" + contact.FirstName + " " + contact.LastName).Send()
 //	})
-func (t *TelegramRouter) Contact(handlers ...HandlerFunc) {
-	t.mu.Lock()
-	t.contactHandlers = append(t.contactHandlers, handlers...)
-	t.composedDirty = true
-	t.mu.Unlock()
+func (g *RouterGroup) Contact(handlers ...HandlerFunc) *RouteHandle {
+	return g.add("contact", &g.router.contactHandlers, handlers)
 }
 
 // Poll 注册轮询处理函数。
 // 可以一次注册多个处理函数，它们会按顺序执行，直到被中断。
-func (t *TelegramRouter) Poll(handlers ...HandlerFunc) {
-	t.mu.Lock()
-	t.pollHandlers = append(t.pollHandlers, handlers...)
-	t.composedDirty = true
-	t.mu.Unlock()
+func (g *RouterGroup) Poll(handlers ...HandlerFunc) *RouteHandle {
+	return g.add("poll", &g.router.pollHandlers, handlers)
 }
 
 // PollWithType 根据类型与条件注册轮询处理器（便捷 API）
-func (t *TelegramRouter) PollWithType(pt PollType, handlers ...HandlerFunc) {
-	t.mu.Lock()
-	t.pollTypeHandlers[pt] = append(t.pollTypeHandlers[pt], handlers...)
-	t.composedDirty = true
-	t.mu.Unlock()
+// 多个条件路由按注册顺序匹配，在 Quiz、RegularPoll 与 Poll 处理器之前执行。
+func (g *RouterGroup) PollWithType(pt PollType, handlers ...HandlerFunc) *RouteHandle {
+	return g.addMatch("pollType", fmt.Sprintf("%+v", pt), &g.router.pollTypeHandlers, func(c *Context) bool {
+		poll := c.Poll
+		// 类型、投票数、匿名设置均需匹配，多选设置仅对 regular 类型有效
+		return (pt.Type == "" || poll.Type == pt.Type) &&
+			(pt.MinVotes == 0 || poll.TotalVoterCount >= pt.MinVotes) &&
+			pt.IsAnonymous == poll.IsAnonymous &&
+			(poll.Type != "regular" || pt.AllowMultiple == poll.AllowsMultipleAnswers)
+	}, handlers...)
 }
 
 // Quiz 注册测验处理函数。
 // 可以一次注册多个处理函数，它们会按顺序执行，直到被中断。
-func (t *TelegramRouter) Quiz(handlers ...HandlerFunc) {
-	t.mu.Lock()
-	t.quizHandlers = append(t.quizHandlers, handlers...)
-	t.composedDirty = true
-	t.mu.Unlock()
+func (g *RouterGroup) Quiz(handlers ...HandlerFunc) *RouteHandle {
+	return g.add("quiz", &g.router.quizHandlers, handlers)
 }
 
 // RegularPoll registers handlers for regular (non-quiz) polls.
@@ -1301,20 +1421,14 @@ func (t *TelegramRouter) Quiz(handlers ...HandlerFunc) {
 //	router.RegularPoll(func(c *Context) {
 //	    log.Printf("Received regular poll: %s", c.Message.Poll.Question)
 //	})
-func (t *TelegramRouter) RegularPoll(handlers ...HandlerFunc) {
-	t.mu.Lock()
-	t.regularPollHandlers = append(t.regularPollHandlers, handlers...)
-	t.composedDirty = true
-	t.mu.Unlock()
+func (g *RouterGroup) RegularPoll(handlers ...HandlerFunc) *RouteHandle {
+	return g.add("regularPoll", &g.router.regularPollHandlers, handlers)
 }
 
 // Game 注册游戏处理函数。
 // 可以一次注册多个处理函数，它们会按顺序执行，直到被中断。
-func (t *TelegramRouter) Game(handlers ...HandlerFunc) {
-	t.mu.Lock()
-	t.gameHandlers = append(t.gameHandlers, handlers...)
-	t.composedDirty = true
-	t.mu.Unlock()
+func (g *RouterGroup) Game(handlers ...HandlerFunc) *RouteHandle {
+	return g.add("game", &g.router.gameHandlers, handlers)
 }
 
 // Voice registers handlers for voice messages.
@@ -1331,11 +1445,8 @@ func (t *TelegramRouter) Game(handlers ...HandlerFunc) {
 //	    voice := c.Message.Voice
 //	    c.Reply("收到语音消息：" + strconv.Itoa(voice.Duration) + " 秒").Send()
 //	})
-func (t *TelegramRouter) Voice(handlers ...HandlerFunc) {
-	t.mu.Lock()
-	t.voiceHandlers = append(t.voiceHandlers, handlers...)
-	t.composedDirty = true
-	t.mu.Unlock()
+func (g *RouterGroup) Voice(handlers ...HandlerFunc) *RouteHandle {
+	return g.add("voice", &g.router.voiceHandlers, handlers)
 }
 
 // VideoNote registers handlers for video note messages.
@@ -1352,11 +1463,8 @@ func (t *TelegramRouter) Voice(handlers ...HandlerFunc) {
 //	    videoNote := c.Message.VideoNote
 //	    c.Reply("收到视频笔记：" + strconv.Itoa(videoNote.Duration) + " 秒").Send()
 //	})
-func (t *TelegramRouter) VideoNote(handlers ...HandlerFunc) {
-	t.mu.Lock()
-	t.videoNoteHandlers = append(t.videoNoteHandlers, handlers...)
-	t.composedDirty = true
-	t.mu.Unlock()
+func (g *RouterGroup) VideoNote(handlers ...HandlerFunc) *RouteHandle {
+	return g.add("videoNote", &g.router.videoNoteHandlers, handlers)
 }
 
 // Animation registers handlers for animation messages.
@@ -1373,11 +1481,8 @@ func (t *TelegramRouter) VideoNote(handlers ...HandlerFunc) {
 //	    anim := c.Message.Animation
 //	    c.Reply("收到动画：" + anim.FileName).Send()
 //	})
-func (t *TelegramRouter) Animation(handlers ...HandlerFunc) {
-	t.mu.Lock()
-	t.animationHandlers = append(t.animationHandlers, handlers...)
-	t.composedDirty = true
-	t.mu.Unlock()
+func (g *RouterGroup) Animation(handlers ...HandlerFunc) *RouteHandle {
+	return g.add("animation", &g.router.animationHandlers, handlers)
 }
 
 // LiveLocation registers handlers for live location updates.
@@ -1394,11 +1499,8 @@ func (t *TelegramRouter) Animation(handlers ...HandlerFunc) {
 //	    loc := c.Message.Location
 //	    c.Reply(fmt.Sprintf("实时位置更新：%.6f, %.6f", loc.Latitude, loc.Longitude)).Send()
 //	})
-func (t *TelegramRouter) LiveLocation(handlers ...HandlerFunc) {
-	t.mu.Lock()
-	t.liveLocationHandlers = append(t.liveLocationHandlers, handlers...)
-	t.composedDirty = true
-	t.mu.Unlock()
+func (g *RouterGroup) LiveLocation(handlers ...HandlerFunc) *RouteHandle {
+	return g.add("liveLocation", &g.router.liveLocationHandlers, handlers)
 }
 
 // ChannelPost registers handlers for channel post messages.
@@ -1414,55 +1516,42 @@ func (t *TelegramRouter) LiveLocation(handlers ...HandlerFunc) {
 //	router.ChannelPost(func(c *Context) {
 //	    c.Reply("收到频道消息：" + c.ChannelPost.Text).Send()
 //	})
-func (t *TelegramRouter) ChannelPost(handlers ...HandlerFunc) {
-	t.mu.Lock()
-	t.channelPostHandlers = append(t.channelPostHandlers, handlers...)
-	t.composedDirty = true
-	t.mu.Unlock()
+func (g *RouterGroup) ChannelPost(handlers ...HandlerFunc) *RouteHandle {
+	return g.add("channelPost", &g.router.channelPostHandlers, handlers)
 }
 
 // LocationInRange 注册位置范围处理器
 // 当位置在指定范围内时触发
-func (t *TelegramRouter) LocationInRange(minLat, maxLat, minLon, maxLon float64, handler HandlerFunc) {
-	t.Location(func(c *Context) {
+func (g *RouterGroup) LocationInRange(minLat, maxLat, minLon, maxLon float64, handler HandlerFunc) *RouteHandle {
+	pattern := fmt.Sprintf("[%g,%g]x[%g,%g]", minLat, maxLat, minLon, maxLon)
+	return g.addMatch("locationRange", pattern, &g.router.locationHandlers, func(c *Context) bool {
 		loc := c.Message.Location
-		if loc.Latitude >= minLat && loc.Latitude <= maxLat &&
-			loc.Longitude >= minLon && loc.Longitude <= maxLon {
-			handler(c)
-		}
-	})
-	t.mu.Lock()
-	t.composedDirty = true
-	t.mu.Unlock()
+		return loc.Latitude >= minLat && loc.Latitude <= maxLat &&
+			loc.Longitude >= minLon && loc.Longitude <= maxLon
+	}, handler)
 }
 
 // DocumentWithType 注册文档类型处理器
 // 当文档类型和大小符合要求时触发
-func (t *TelegramRouter) DocumentWithType(mimeType string, maxSize int, handler HandlerFunc) {
-	t.Document(func(c *Context) {
+func (g *RouterGroup) DocumentWithType(mimeType string, maxSize int, handler HandlerFunc) *RouteHandle {
+	pattern := fmt.Sprintf("%s<=%d", mimeType, maxSize)
+	return g.addMatch("documentType", pattern, &g.router.documentHandlers, func(c *Context) bool {
 		doc := c.Message.Document
-		if (mimeType == "" || doc.MimeType == mimeType) &&
-			(maxSize == 0 || doc.FileSize <= maxSize) {
-			handler(c)
-		}
-	})
-	t.mu.Lock()
-	t.composedDirty = true
-	t.mu.Unlock()
+		return (mimeType == "" || doc.MimeType == mimeType) &&
+			(maxSize == 0 || doc.FileSize <= maxSize)
+	}, handler)
 }
 
 // applyMiddlewares 应用中间件到处理函数。
-// 按照注册顺序从后向前应用中间件，形成处理链。
-func (t *TelegramRouter) applyMiddlewares(handler HandlerFunc) HandlerFunc {
+// 处理链依次为：全局中间件、分组中间件（由外到内）、处理函数。
+// 处理链只在组合缓存时构建一次，调用方需持有写锁。
+func (t *TelegramRouter) applyMiddlewares(group *RouterGroup, handlers ...HandlerFunc) HandlerFunc {
+	groupMws := group.chain()
+	chain := make([]HandlerFunc, 0, len(t.middlewares)+len(groupMws)+len(handlers))
+	chain = append(chain, t.middlewares...)
+	chain = append(chain, groupMws...)
+	chain = append(chain, handlers...)
 	return func(c *Context) {
-		// 创建一个新的处理链，包含所有中间件和原始处理链
-		t.mu.RLock()
-		mws := make([]HandlerFunc, len(t.middlewares))
-		copy(mws, t.middlewares)
-		t.mu.RUnlock()
-		chain := make([]HandlerFunc, 0, len(mws)+1)
-		chain = append(chain, mws...)
-		chain = append(chain, handler)
 		c.handlers = chain
 		c.index = -1
 		c.Next()
@@ -1471,118 +1560,138 @@ func (t *TelegramRouter) applyMiddlewares(handler HandlerFunc) HandlerFunc {
 
 // composeHandlers 将所有注册的处理器与中间件组合并缓存，避免分发时重复包装
 func (t *TelegramRouter) composeHandlers() {
-	if !t.composedDirty {
-		return
-	}
 	// 组合期间持有写锁，避免并发注册改动
 	t.mu.Lock()
 	defer t.mu.Unlock()
+	if !t.composedDirty && t.composed.Load() != nil {
+		return
+	}
+	rt := &routeTable{}
 
-	wrapMany := func(src []HandlerFunc) []HandlerFunc {
+	// 每个处理函数单独包装一条处理链
+	wrapMany := func(src []*route) []composedHandler {
 		if len(src) == 0 {
 			return nil
 		}
-		out := make([]HandlerFunc, 0, len(src))
-		for _, h := range src {
-			out = append(out, t.applyMiddlewares(h))
+		out := make([]composedHandler, 0, len(src))
+		for _, r := range src {
+			sc := r.group.scope()
+			policy := t.policyFor(r.group)
+			for _, h := range r.handlers {
+				hs := []HandlerFunc{h}
+				if r.args != nil {
+					// 在中间件之后、处理函数之前解析命令参数
+					hs = []HandlerFunc{r.args.bind, h}
+				}
+				out = append(out, composedHandler{scope: sc, handler: t.applyMiddlewares(r.group, hs...), match: r.match, policy: policy, route: r})
+			}
 		}
 		return out
 	}
 
-	t.textHandlersC = wrapMany(t.textHandlers)
-	t.documentHandlersC = wrapMany(t.documentHandlers)
-	t.audioHandlersC = wrapMany(t.audioHandlers)
-	t.videoHandlersC = wrapMany(t.videoHandlers)
-	t.photoHandlersC = wrapMany(t.photoHandlers)
-	t.stickerHandlersC = wrapMany(t.stickerHandlers)
-	t.callbackHandlersC = wrapMany(t.callbackHandlers)
-	t.locationHandlersC = wrapMany(t.locationHandlers)
-	t.contactHandlersC = wrapMany(t.contactHandlers)
-	t.pollHandlersC = wrapMany(t.pollHandlers)
-	t.quizHandlersC = wrapMany(t.quizHandlers)
-	t.regularPollHandlersC = wrapMany(t.regularPollHandlers)
-	t.gameHandlersC = wrapMany(t.gameHandlers)
-	t.voiceHandlersC = wrapMany(t.voiceHandlers)
-	t.videoNoteHandlersC = wrapMany(t.videoNoteHandlers)
-	t.animationHandlersC = wrapMany(t.animationHandlers)
-	t.liveLocationHandlersC = wrapMany(t.liveLocationHandlers)
-	t.channelPostHandlersC = wrapMany(t.channelPostHandlers)
-	t.inlineQueryHandlersC = wrapMany(t.inlineQueryHandlers)
-	t.chosenInlineResultHandlersC = wrapMany(t.chosenInlineResultHandlers)
-	t.groupChatCreatedHandlersC = wrapMany(t.groupChatCreatedHandlers)
-	t.supergroupChatCreatedHandlersC = wrapMany(t.supergroupChatCreatedHandlers)
-	t.channelChatCreatedHandlersC = wrapMany(t.channelChatCreatedHandlers)
-	t.newChatMembersHandlersC = wrapMany(t.newChatMembersHandlers)
-	t.leftChatMemberHandlersC = wrapMany(t.leftChatMemberHandlers)
-	t.newChatTitleHandlersC = wrapMany(t.newChatTitleHandlers)
-	t.newChatPhotoHandlersC = wrapMany(t.newChatPhotoHandlers)
-	t.deleteChatPhotoHandlersC = wrapMany(t.deleteChatPhotoHandlers)
-	t.editedMessageHandlersC = wrapMany(t.editedMessageHandlers)
-	t.editedChannelPostHandlersC = wrapMany(t.editedChannelPostHandlers)
-	t.myChatMemberHandlersC = wrapMany(t.myChatMemberHandlers)
-	t.chatMemberHandlersC = wrapMany(t.chatMemberHandlers)
-	t.pollAnswerHandlersC = wrapMany(t.pollAnswerHandlers)
-	t.preCheckoutQueryHandlersC = wrapMany(t.preCheckoutQueryHandlers)
-	t.shippingQueryHandlersC = wrapMany(t.shippingQueryHandlers)
-	t.successfulPaymentHandlersC = wrapMany(t.successfulPaymentHandlers)
-
-	if len(t.pollTypeHandlers) > 0 {
-		t.pollTypeHandlersC = make(map[PollType][]HandlerFunc, len(t.pollTypeHandlers))
-		for k, v := range t.pollTypeHandlers {
-			t.pollTypeHandlersC[k] = wrapMany(v)
-		}
-	} else {
-		t.pollTypeHandlersC = nil
-	}
-
-	if len(t.locationRangeHandlers) > 0 {
-		t.locationRangeHandlersC = make(map[LocationRange][]HandlerFunc, len(t.locationRangeHandlers))
-		for k, v := range t.locationRangeHandlers {
-			t.locationRangeHandlersC[k] = wrapMany(v)
-		}
-	} else {
-		t.locationRangeHandlersC = nil
-	}
-
-	if len(t.documentTypeHandlers) > 0 {
-		t.documentTypeHandlersC = make(map[FileType][]HandlerFunc, len(t.documentTypeHandlers))
-		for k, v := range t.documentTypeHandlers {
-			t.documentTypeHandlersC[k] = wrapMany(v)
-		}
-	} else {
-		t.documentTypeHandlersC = nil
-	}
-
-	// Callback 路由本身持有 handler，这里也包装一层后缓存
+	rt.textHandlersC = wrapMany(t.textHandlers)
+	rt.documentHandlersC = wrapMany(t.documentHandlers)
+	rt.audioHandlersC = wrapMany(t.audioHandlers)
+	rt.videoHandlersC = wrapMany(t.videoHandlers)
+	rt.photoHandlersC = wrapMany(t.photoHandlers)
+	rt.stickerHandlersC = wrapMany(t.stickerHandlers)
+	rt.mediaGroupHandlersC = wrapMany(t.mediaGroupHandlers)
+	rt.callbackHandlersC = wrapMany(t.callbackHandlers)
+	rt.noRouteHandlersC = wrapMany(t.noRouteHandlers)
+	rt.noCommandHandlersC = wrapMany(t.noCommandHandlers)
+	rt.noCallbackHandlersC = wrapMany(t.noCallbackHandlers)
+	rt.locationHandlersC = wrapMany(t.locationHandlers)
+	rt.contactHandlersC = wrapMany(t.contactHandlers)
+	rt.pollHandlersC = wrapMany(t.pollHandlers)
+	rt.pollTypeHandlersC = wrapMany(t.pollTypeHandlers)
+	rt.quizHandlersC = wrapMany(t.quizHandlers)
+	rt.regularPollHandlersC = wrapMany(t.regularPollHandlers)
+	rt.gameHandlersC = wrapMany(t.gameHandlers)
+	rt.voiceHandlersC = wrapMany(t.voiceHandlers)
+	rt.videoNoteHandlersC = wrapMany(t.videoNoteHandlers)
+	rt.animationHandlersC = wrapMany(t.animationHandlers)
+	rt.liveLocationHandlersC = wrapMany(t.liveLocationHandlers)
+	rt.channelPostHandlersC = wrapMany(t.channelPostHandlers)
+	rt.inlineQueryHandlersC = wrapMany(t.inlineQueryHandlers)
+	rt.chosenInlineResultHandlersC = wrapMany(t.chosenInlineResultHandlers)
+	rt.groupChatCreatedHandlersC = wrapMany(t.groupChatCreatedHandlers)
+	rt.supergroupChatCreatedHandlersC = wrapMany(t.supergroupChatCreatedHandlers)
+	rt.channelChatCreatedHandlersC = wrapMany(t.channelChatCreatedHandlers)
+	rt.newChatMembersHandlersC = wrapMany(t.newChatMembersHandlers)
+	rt.leftChatMemberHandlersC = wrapMany(t.leftChatMemberHandlers)
+	rt.newChatTitleHandlersC = wrapMany(t.newChatTitleHandlers)
+	rt.newChatPhotoHandlersC = wrapMany(t.newChatPhotoHandlers)
+	rt.deleteChatPhotoHandlersC = wrapMany(t.deleteChatPhotoHandlers)
+	rt.editedMessageHandlersC = wrapMany(t.editedMessageHandlers)
+	rt.editedChannelPostHandlersC = wrapMany(t.editedChannelPostHandlers)
+	// ChannelPost 处理函数在任何分组中注册都只处理频道消息
+	for i := range rt.channelPostHandlersC {
+		rt.channelPostHandlersC[i].scope.channel = true
+	}
+	rt.myChatMemberHandlersC = wrapMany(t.myChatMemberHandlers)
+	rt.chatMemberHandlersC = wrapMany(t.chatMemberHandlers)
+	rt.pollAnswerHandlersC = wrapMany(t.pollAnswerHandlers)
+	rt.preCheckoutQueryHandlersC = wrapMany(t.preCheckoutQueryHandlers)
+	rt.shippingQueryHandlersC = wrapMany(t.shippingQueryHandlers)
+	rt.successfulPaymentHandlersC = wrapMany(t.successfulPaymentHandlers)
+
+	// 通用更新处理器：一次注册的处理函数共用一条处理链
+	for _, r := range t.updateHandlers {
+		rt.updateHandlersC = append(rt.updateHandlersC, composedHandler{
+			scope:   r.group.scope(),
+			handler: t.applyMiddlewares(r.group, r.handlers...),
+		})
+	}
+
+	// Callback 路由组合中间件后构建前缀树，同时报告匹配相同回调数据的冲突路由
 	if len(t.callbackRoutes) > 0 {
-		t.callbackRoutesC = make([]*CallbackRoute, 0, len(t.callbackRoutes))
+		rt.callbackTrieC = newCallbackNode()
 		for _, r := range t.callbackRoutes {
-			cr := &CallbackRoute{pattern: r.pattern, params: r.params, regex: r.regex}
-			cr.handler = t.applyMiddlewares(r.handler)
-			t.callbackRoutesC = append(t.callbackRoutesC, cr)
+			cr := &CallbackRoute{route: r.route, params: r.params, scope: r.group.scope(), policy: t.policyFor(r.group)}
+			cr.handler = t.applyMiddlewares(r.group, r.handlers...)
+			for _, prev := range rt.callbackTrieC.insert(cr) {
+				rt.callbackConflictsC = append(rt.callbackConflictsC, [2]*CallbackRoute{cr, prev})
+				if t.Logger != nil {
+					t.Logger.Printf("回调路由冲突: %q 与 %q 匹配相同的回调数据", cr.pattern, prev.pattern)
+				}
+			}
 		}
-	} else {
-		t.callbackRoutesC = nil
 	}
 
 	// 命令
 	if len(t.commandHandlers) > 0 {
-		t.commandHandlersC = make(map[string][]HandlerFunc, len(t.commandHandlers))
+		rt.commandHandlersC = make(map[string][]composedHandler, len(t.commandHandlers))
 		for k, v := range t.commandHandlers {
-			t.commandHandlersC[k] = wrapMany(v)
+			rt.commandHandlersC[k] = wrapMany(v)
 		}
-	} else {
-		t.commandHandlersC = nil
 	}
 	if len(t.commandRegexRoutes) > 0 {
-		t.commandRegexRoutesC = make([]*CommandRegexRoute, 0, len(t.commandRegexRoutes))
+		rt.commandRegexRoutesC = make([]*CommandRegexRoute, 0, len(t.commandRegexRoutes))
 		for _, r := range t.commandRegexRoutes {
-			t.commandRegexRoutesC = append(t.commandRegexRoutesC, &CommandRegexRoute{regex: r.regex, handlers: wrapMany(r.handlers)})
+			composed := wrapMany([]*route{r.route})
+			rt.commandRegexRoutesC = append(rt.commandRegexRoutesC, &CommandRegexRoute{route: r.route, regex: r.regex, composed: composed})
+		}
+	}
+	if len(t.startPayloadRoutes) > 0 {
+		rt.startPayloadRoutesC = make([]*StartPayloadRoute, 0, len(t.startPayloadRoutes))
+		for _, r := range t.startPayloadRoutes {
+			sr := *r
+			sr.composed = wrapMany([]*route{r.route})
+			rt.startPayloadRoutesC = append(rt.startPayloadRoutesC, &sr)
+		}
+	}
+
+	// 谓词路由
+	if len(t.filterRoutes) > 0 {
+		rt.filterRoutesC = make([]*FilterRoute, 0, len(t.filterRoutes))
+		for _, r := range t.filterRoutes {
+			fr := &FilterRoute{route: r.route, filter: r.filter, scope: r.group.scope(), policy: t.policyFor(r.group)}
+			fr.handler = t.applyMiddlewares(r.group, r.handlers...)
+			rt.filterRoutesC = append(rt.filterRoutesC, fr)
 		}
-	} else {
-		t.commandRegexRoutesC = nil
 	}
 
+	t.composed.Store(rt)
 	t.composedDirty = false
 }
 
@@ -1594,575 +1703,436 @@ func (t *TelegramRouter) SetLogger(logger *log.Logger) *TelegramRouter {
 	return t
 }
 
+// snapshot 返回当前的路由表快照，注册有变化时先重新组合
+func (t *TelegramRouter) snapshot() *routeTable {
+	t.mu.RLock()
+	dirty := t.composedDirty
+	t.mu.RUnlock()
+	if rt := t.composed.Load(); rt != nil && !dirty {
+		return rt
+	}
+	t.composeHandlers()
+	return t.composed.Load()
+}
+
 // HandleUpdate 处理 Telegram 更新消息。
 // 根据消息类型分发到对应的处理函数，并应用中间件。
 // 支持命令、文本、文档、音频、视频、照片、贴纸和回调查询等消息类型。
 func (t *TelegramRouter) HandleUpdate(update *tgbotapi.Update) {
-	if t.composedDirty {
-		t.composeHandlers()
+	rt := t.snapshot()
+	c := t.newContext(context.Background(), update)
+	// 处理链结束后写回会话修改
+	defer t.saveSession(c)
+
+	t.forgetChatMember(update)
+	t.dispatch(c, rt, update)
+
+	// 没有任何路由处理时执行兜底处理器
+	if !c.matched && !c.IsAborted() && c.reachesNoRoute() {
+		t.run(c, rt.noRouteHandlersC)
 	}
-	c := &Context{
-		Context:  context.Background(),
+}
+
+// newContext 为更新创建处理上下文
+func (t *TelegramRouter) newContext(ctx context.Context, update *tgbotapi.Update) *Context {
+	return &Context{
+		Context:  ctx,
 		Update:   update,
 		Bot:      t.Bot,
 		Logger:   t.Logger,
+		router:   t,
 		index:    -1,
 		handlers: nil,
 		aborted:  false,
 		params:   make(map[string]string),
 		query:    make(map[string]string),
 	}
+}
+
+// dispatch 将更新分发到匹配的处理函数，匹配结果记录在 c.matched
+func (t *TelegramRouter) dispatch(c *Context, rt *routeTable, update *tgbotapi.Update) {
+	// 将服务端存储的令牌还原为原始回调数据，所有处理函数与谓词都只看到原始数据
+	t.restoreCallbackData(c)
 
 	// 首先执行通用更新处理器
-	if len(t.updateHandlers) > 0 {
-		for _, handler := range t.updateHandlers {
-			if c.IsAborted() {
-				return
-			}
-			handler(c)
+	for _, h := range rt.updateHandlersC {
+		if c.IsAborted() {
+			return
+		}
+		if h.scope.match(c) {
+			h.handler(c)
 		}
 	}
 
+	// 谓词路由：默认第一个匹配的路由处理后不再按类型分发。
+	// 频道消息先转换为普通消息，与其他路由一样只交给频道分组
+	t.routeChannelPost(c)
+	if !c.IsAborted() && t.runFilterRoutes(c, rt) {
+		return
+	}
+
 	// 如果通用处理器没有中断，继续执行特定类型的处理器
 	if !c.IsAborted() {
+		// 处理频道消息：之后按普通消息分发，只有频道分组（Channel）的路由匹配
+		if update.ChannelPost != nil && update.Message == nil {
+			t.routeChannelPost(c)
+			update = c.Update
+			t.run(c, rt.channelPostHandlersC)
+			if c.IsAborted() {
+				return
+			}
+		}
+
 		// 处理群组相关事件
 		if update.Message != nil {
 			// 处理群组聊天创建
 			if update.Message.GroupChatCreated {
-				for _, h := range t.groupChatCreatedHandlersC {
-					h(c)
-					if c.IsAborted() {
-						return
-					}
+				t.run(c, rt.groupChatCreatedHandlersC)
+				if c.IsAborted() {
+					return
 				}
 			}
 
 			// 处理超级群组聊天创建
 			if update.Message.SuperGroupChatCreated {
-				for _, h := range t.supergroupChatCreatedHandlersC {
-					h(c)
-					if c.IsAborted() {
-						return
-					}
+				t.run(c, rt.supergroupChatCreatedHandlersC)
+				if c.IsAborted() {
+					return
 				}
 			}
 
 			// 处理频道聊天创建
 			if update.Message.ChannelChatCreated {
-				for _, h := range t.channelChatCreatedHandlersC {
-					h(c)
-					if c.IsAborted() {
-						return
-					}
+				t.run(c, rt.channelChatCreatedHandlersC)
+				if c.IsAborted() {
+					return
 				}
 			}
 
 			// 处理新聊天成员
 			if len(update.Message.NewChatMembers) > 0 {
-				for _, h := range t.newChatMembersHandlersC {
-					h(c)
-					if c.IsAborted() {
-						return
-					}
+				t.run(c, rt.newChatMembersHandlersC)
+				if c.IsAborted() {
+					return
 				}
 			}
 
 			// 处理离开聊天成员
 			if update.Message.LeftChatMember != nil {
-				for _, h := range t.leftChatMemberHandlersC {
-					h(c)
-					if c.IsAborted() {
-						return
-					}
+				t.run(c, rt.leftChatMemberHandlersC)
+				if c.IsAborted() {
+					return
 				}
 			}
 
 			// 处理新聊天标题
 			if update.Message.NewChatTitle != "" {
-				for _, h := range t.newChatTitleHandlersC {
-					h(c)
-					if c.IsAborted() {
-						return
-					}
+				t.run(c, rt.newChatTitleHandlersC)
+				if c.IsAborted() {
+					return
 				}
 			}
 
 			// 处理新聊天照片
 			if len(update.Message.NewChatPhoto) > 0 {
-				for _, h := range t.newChatPhotoHandlersC {
-					h(c)
-					if c.IsAborted() {
-						return
-					}
+				t.run(c, rt.newChatPhotoHandlersC)
+				if c.IsAborted() {
+					return
 				}
 			}
 
 			// 处理删除聊天照片
 			if update.Message.DeleteChatPhoto {
-				for _, h := range t.deleteChatPhotoHandlersC {
-					h(c)
-					if c.IsAborted() {
-						return
-					}
+				t.run(c, rt.deleteChatPhotoHandlersC)
+				if c.IsAborted() {
+					return
 				}
 			}
 		}
 
 		// 处理编辑后的消息
 		if update.EditedMessage != nil {
-			for _, h := range t.editedMessageHandlersC {
-				h(c)
-				if c.IsAborted() {
-					return
-				}
+			t.run(c, rt.editedMessageHandlersC)
+			if c.IsAborted() {
+				return
 			}
+			// 开启编辑消息分发时，按普通消息继续分发到命令、文本、媒体路由
+			t.routeEdited(c)
+			update = c.Update
 		}
 
 		// 处理编辑后的频道消息
 		if update.EditedChannelPost != nil {
-			for _, h := range t.editedChannelPostHandlersC {
-				h(c)
-				if c.IsAborted() {
-					return
-				}
+			t.run(c, rt.editedChannelPostHandlersC)
+			if c.IsAborted() {
+				return
 			}
 		}
 
 		// 处理我的聊天成员更新
 		if update.MyChatMember != nil {
-			for _, h := range t.myChatMemberHandlersC {
-				h(c)
-				if c.IsAborted() {
-					return
-				}
+			t.run(c, rt.myChatMemberHandlersC)
+			if c.IsAborted() {
+				return
 			}
 		}
 
 		// 处理聊天成员更新
 		if update.ChatMember != nil {
-			for _, h := range t.chatMemberHandlersC {
-				h(c)
-				if c.IsAborted() {
-					return
-				}
+			t.run(c, rt.chatMemberHandlersC)
+			if c.IsAborted() {
+				return
 			}
 		}
 
 		// 处理投票答案
 		if update.PollAnswer != nil {
-			for _, h := range t.pollAnswerHandlersC {
-				h(c)
-				if c.IsAborted() {
-					return
-				}
+			t.run(c, rt.pollAnswerHandlersC)
+			if c.IsAborted() {
+				return
 			}
 		}
 
 		// 处理预结账查询
 		if update.PreCheckoutQuery != nil {
-			for _, h := range t.preCheckoutQueryHandlersC {
-				h(c)
-				if c.IsAborted() {
-					return
-				}
+			t.run(c, rt.preCheckoutQueryHandlersC)
+			if c.IsAborted() {
+				return
 			}
 		}
 
 		// 处理运费查询
 		if update.ShippingQuery != nil {
-			for _, h := range t.shippingQueryHandlersC {
-				h(c)
-				if c.IsAborted() {
-					return
-				}
+			t.run(c, rt.shippingQueryHandlersC)
+			if c.IsAborted() {
+				return
 			}
 		}
 
 		// 处理成功支付
 		if update.Message != nil && update.Message.SuccessfulPayment != nil {
-			for _, h := range t.successfulPaymentHandlersC {
-				h(c)
-				if c.IsAborted() {
-					return
-				}
+			t.run(c, rt.successfulPaymentHandlersC)
+			if c.IsAborted() {
+				return
 			}
 		}
 
+		// 忽略通过 "/cmd@OtherBot" 指定给其他机器人的命令
+		if update.Message != nil && update.Message.IsCommand() && t.commandForOtherBot(update.Message) {
+			c.ignored = true
+			return
+		}
+
 		// 处理命令消息
 		if update.Message != nil && update.Message.IsCommand() {
 			cmd := update.Message.Command()
-			if handlers, ok := t.commandHandlersC[cmd]; ok {
-				for _, h := range handlers {
-					h(c)
-					if c.IsAborted() {
-						return
+			// 默认策略下第一组匹配的命令路由执行后不再继续
+			hit := false
+			done := func() bool {
+				hit = true
+				return c.IsAborted() || c.lastPolicy == DispatchDefault
+			}
+			if payload := c.StartPayload(); payload != "" {
+				for _, route := range rt.startPayloadRoutesC {
+					params, ok := route.match(payload)
+					if !ok {
+						continue
+					}
+					c.params = params
+					if t.run(c, route.composed) {
+						if done() {
+							return
+						}
+						continue
 					}
+					c.params = nil
 				}
+			}
+			if t.run(c, rt.commandHandlersC[cmd]) && done() {
 				return
 			}
-			if len(t.commandRegexRoutesC) > 0 {
-				for _, route := range t.commandRegexRoutesC {
-					if route.regex.MatchString(cmd) {
-						for _, h := range route.handlers {
-							h(c)
-							if c.IsAborted() {
-								return
-							}
-						}
-						return
-					}
+			for _, route := range rt.commandRegexRoutesC {
+				if route.regex.MatchString(cmd) && t.run(c, route.composed) && done() {
+					return
 				}
 			}
+			if hit {
+				return
+			}
+			// 未知命令
+			if t.run(c, rt.noCommandHandlersC) {
+				return
+			}
 		}
 
 		// 处理文本消息
 		if update.Message != nil && update.Message.Text != "" {
-			for _, handler := range t.textHandlersC {
-				handler(c)
-				if c.IsAborted() {
-					return
-				}
-			}
+			t.run(c, rt.textHandlersC)
 			return
 		}
 
 		// 处理 Inline 模式
 		if update.InlineQuery != nil {
-			for _, handler := range t.inlineQueryHandlersC {
-				handler(c)
-				if c.IsAborted() {
-					return
-				}
-			}
+			t.run(c, rt.inlineQueryHandlersC)
 			return
 		}
 		if update.ChosenInlineResult != nil {
-			for _, handler := range t.chosenInlineResultHandlersC {
-				handler(c)
-				if c.IsAborted() {
-					return
-				}
-			}
+			t.run(c, rt.chosenInlineResultHandlersC)
+			return
+		}
+
+		// 处理媒体组：同一相册的消息缓冲后合并为一次调用
+		if t.bufferMediaGroup(c, rt) {
+			c.matched = true
 			return
 		}
 
 		// 处理文档消息
 		if update.Message != nil && update.Message.Document != nil {
-			for _, handler := range t.documentHandlersC {
-				handler(c)
-				if c.IsAborted() {
-					return
-				}
-			}
+			t.run(c, rt.documentHandlersC)
 			return
 		}
 
 		// 处理音频消息
 		if update.Message != nil && update.Message.Audio != nil {
-			for _, handler := range t.audioHandlersC {
-				handler(c)
-				if c.IsAborted() {
-					return
-				}
-			}
+			t.run(c, rt.audioHandlersC)
 			return
 		}
 
 		// 处理视频消息
 		if update.Message != nil && update.Message.Video != nil {
-			for _, handler := range t.videoHandlersC {
-				handler(c)
-				if c.IsAborted() {
-					return
-				}
-			}
+			t.run(c, rt.videoHandlersC)
 			return
 		}
 
 		// 处理照片消息
 		if update.Message != nil && len(update.Message.Photo) > 0 {
-			for _, handler := range t.photoHandlersC {
-				handler(c)
-				if c.IsAborted() {
-					return
-				}
-			}
+			t.run(c, rt.photoHandlersC)
 			return
 		}
 
 		// 处理贴纸消息
 		if update.Message != nil && update.Message.Sticker != nil {
-			for _, handler := range t.stickerHandlersC {
-				handler(c)
-				if c.IsAborted() {
-					return
-				}
-			}
+			t.run(c, rt.stickerHandlersC)
 			return
 		}
 
 		// 处理回调查询
 		if update.CallbackQuery != nil {
-			callback := update.CallbackQuery
-
-			// 解析回调数据中的查询参数
-			if idx := strings.Index(callback.Data, "?"); idx != -1 {
-				// 分离路径和查询参数
-				path := callback.Data[:idx]
-				queryStr := callback.Data[idx+1:]
-				c.query = parseQuery(queryStr)
-
-				// 尝试匹配路由（使用路径部分）
-				for _, route := range t.callbackRoutesC {
-					matches := route.regex.FindStringSubmatch(path)
-					if matches != nil {
-						// 提取参数
-						params := make(map[string]string)
-						for i, name := range route.params {
-							if i+1 < len(matches) {
-								params[name] = matches[i+1]
-							}
-						}
+			path := update.CallbackQuery.Data
 
-						// 设置参数到上下文
-						c.params = params
+			// 解析回调数据中的查询参数，路由只匹配路径部分
+			if idx := strings.Index(path, "?"); idx != -1 {
+				c.query = parseQuery(path[idx+1:])
+				path = path[:idx]
+			}
 
-						// 执行处理函数
-						route.handler(c)
-						if c.IsAborted() {
-							return
-						}
+			// 按优先级匹配路由：静态段 > 参数段 > 通配符
+			// 默认策略下只执行优先级最高的路由，开启 SetCallbackFallThrough 时依次执行
+			t.mu.RLock()
+			fallThrough := t.callbackFallThrough
+			t.mu.RUnlock()
+			accept := func(r *CallbackRoute) bool { return r.scope.match(c) }
+			hit := false
+			for _, m := range rt.callbackTrieC.lookup(path, true, accept) {
+				hit = true
+				c.matched = true
+				c.params = m.params
+				m.route.handler(c)
+				if m.route.policy != DispatchDefault {
+					if t.settle(c, m.route.policy) {
+						return
 					}
+					continue
 				}
-			} else {
-				// 没有查询参数，直接匹配整个回调数据
-				for _, route := range t.callbackRoutesC {
-					matches := route.regex.FindStringSubmatch(callback.Data)
-					if matches != nil {
-						// 提取参数
-						params := make(map[string]string)
-						for i, name := range route.params {
-							if i+1 < len(matches) {
-								params[name] = matches[i+1]
-							}
-						}
-
-						// 设置参数到上下文
-						c.params = params
-
-						// 执行处理函数
-						route.handler(c)
-						if c.IsAborted() {
-							return
-						}
-					}
+				if c.IsAborted() {
+					return
+				}
+				if !fallThrough {
+					break
 				}
 			}
 
 			// 处理未匹配的回调（通用处理器）
-			for _, handler := range t.callbackHandlersC {
-				handler(c)
-				if c.IsAborted() {
-					return
-				}
+			if !t.run(c, rt.callbackHandlersC) && !hit {
+				t.run(c, rt.noCallbackHandlersC)
 			}
 			return
 		}
 
-		// 处理位置消息
+		// 处理位置消息（LocationInRange 注册的范围路由与普通位置路由按注册顺序匹配）
 		if update.Message != nil && update.Message.Location != nil {
-			loc := update.Message.Location
-
-			// 检查是否在某个范围内
-			for range_, handlers := range t.locationRangeHandlersC {
-				if loc.Latitude >= range_.MinLat && loc.Latitude <= range_.MaxLat &&
-					loc.Longitude >= range_.MinLon && loc.Longitude <= range_.MaxLon {
-					for _, handler := range handlers {
-						handler(c)
-						if c.IsAborted() {
-							return
-						}
-					}
-				}
-			}
-
-			// 处理普通位置消息
-			for _, handler := range t.locationHandlersC {
-				handler(c)
-				if c.IsAborted() {
-					return
-				}
-			}
+			t.run(c, rt.locationHandlersC)
 			return
 		}
 
 		// 处理联系信息
 		if update.Message != nil && update.Message.Contact != nil {
-			for _, handler := range t.contactHandlers {
-				handler = t.applyMiddlewares(handler)
-				handler(c)
-				if c.IsAborted() {
-					return
-				}
-			}
+			t.run(c, rt.contactHandlersC)
 			return
 		}
 
 		// 处理轮询消息
 		if update.Poll != nil {
-			poll := update.Poll
-
-			// 检查轮询类型和条件
-			for pollType, handlers := range t.pollTypeHandlersC {
-				// 检查类型匹配
-				typeMatch := pollType.Type == "" || poll.Type == pollType.Type
-				// 检查投票数
-				votesMatch := pollType.MinVotes == 0 || poll.TotalVoterCount >= pollType.MinVotes
-				// 检查匿名设置
-				anonymousMatch := pollType.IsAnonymous == poll.IsAnonymous
-				// 检查多选设置（仅对 regular 类型有效）
-				multipleMatch := poll.Type != "regular" || pollType.AllowMultiple == poll.AllowsMultipleAnswers
-
-				if typeMatch && votesMatch && anonymousMatch && multipleMatch {
-					for _, handler := range handlers {
-						handler(c)
-						if c.IsAborted() {
-							return
-						}
-					}
-				}
+			// 按类型与条件匹配的轮询路由（按注册顺序）
+			t.run(c, rt.pollTypeHandlersC)
+			if c.IsAborted() {
+				return
 			}
 
 			// 根据轮询类型分发到对应的处理器
-			if poll.Type == "quiz" {
+			if update.Poll.Type == "quiz" {
 				// 处理测验
-				for _, handler := range t.quizHandlersC {
-					handler(c)
-					if c.IsAborted() {
-						return
-					}
+				t.run(c, rt.quizHandlersC)
+				if c.IsAborted() {
+					return
 				}
 			} else {
 				// 处理普通投票
-				for _, handler := range t.regularPollHandlersC {
-					handler(c)
-					if c.IsAborted() {
-						return
-					}
-				}
-			}
-
-			// 处理所有轮询（通用处理器）
-			for _, handler := range t.pollHandlersC {
-				handler(c)
+				t.run(c, rt.regularPollHandlersC)
 				if c.IsAborted() {
 					return
 				}
 			}
+
+			// 处理所有轮询（通用处理器）
+			t.run(c, rt.pollHandlersC)
 			return
 		}
 
 		// 处理投票
 		if update.Message != nil && update.Message.Poll != nil && update.Message.Poll.Type == "quiz" {
-			for _, handler := range t.quizHandlersC {
-				handler(c)
-				if c.IsAborted() {
-					return
-				}
-			}
+			t.run(c, rt.quizHandlersC)
 			return
 		}
 
 		// 处理游戏
 		if update.Message != nil && update.Message.Game != nil {
-			for _, handler := range t.gameHandlersC {
-				handler(c)
-				if c.IsAborted() {
-					return
-				}
-			}
+			t.run(c, rt.gameHandlersC)
 			return
 		}
 
 		// 处理语音消息
 		if update.Message != nil && update.Message.Voice != nil {
-			for _, handler := range t.voiceHandlersC {
-				handler(c)
-				if c.IsAborted() {
-					return
-				}
-			}
+			t.run(c, rt.voiceHandlersC)
 			return
 		}
 
 		// 处理视频笔记
 		if update.Message != nil && update.Message.VideoNote != nil {
-			for _, handler := range t.videoNoteHandlersC {
-				handler(c)
-				if c.IsAborted() {
-					return
-				}
-			}
+			t.run(c, rt.videoNoteHandlersC)
 			return
 		}
 
 		// 处理动画
 		if update.Message != nil && update.Message.Animation != nil {
-			for _, handler := range t.animationHandlersC {
-				handler(c)
-				if c.IsAborted() {
-					return
-				}
-			}
+			t.run(c, rt.animationHandlersC)
 			return
 		}
 
 		// 处理位置共享
 		if update.Message != nil && update.Message.Location != nil && update.Message.Location.LivePeriod > 0 {
-			for _, handler := range t.liveLocationHandlersC {
-				handler(c)
-				if c.IsAborted() {
-					return
-				}
-			}
-			return
-		}
-
-		// 处理群组/频道消息
-		if update.ChannelPost != nil {
-			for _, handler := range t.channelPostHandlersC {
-				handler(c)
-				if c.IsAborted() {
-					return
-				}
-			}
-			return
-		}
-
-		// 处理文档类型消息
-		if update.Message != nil && update.Message.Document != nil {
-			doc := update.Message.Document
-
-			// 检查文档类型和大小
-			for fileType, handlers := range t.documentTypeHandlersC {
-				if (fileType.MimeType == "" || doc.MimeType == fileType.MimeType) &&
-					(fileType.MaxSize == 0 || doc.FileSize <= fileType.MaxSize) {
-					for _, handler := range handlers {
-						handler(c)
-						if c.IsAborted() {
-							return
-						}
-					}
-				}
-			}
-
-			// 处理普通文档消息
-			for _, handler := range t.documentHandlersC {
-				handler(c)
-				if c.IsAborted() {
-					return
-				}
-			}
+			t.run(c, rt.liveLocationHandlersC)
 			return
 		}
 	}
@@ -2190,11 +2160,16 @@ func (t *TelegramRouter) SetErrorReporter(r ErrorReporter) *TelegramRouter {
 
 // TextMessageBuilder 文本消息构建器
 type TextMessageBuilder struct {
+	MessageOptions[*TextMessageBuilder]
 	Msg *tgbotapi.MessageConfig
 	bot *tgbotapi.BotAPI
+	err error // 创建时的错误，由 Send 返回
 }
 
 func (b *TextMessageBuilder) Send() (tgbotapi.Message, error) {
+	if b.err != nil {
+		return tgbotapi.Message{}, b.err
+	}
 	return b.bot.Send(*b.Msg)
 }
 
@@ -2215,6 +2190,7 @@ func (b *TextMessageBuilder) WithParseMode(mode string) *TextMessageBuilder {
 
 // PhotoMessageBuilder 图片消息构建器
 type PhotoMessageBuilder struct {
+	MessageOptions[*PhotoMessageBuilder]
 	Msg *tgbotapi.PhotoConfig
 	bot *tgbotapi.BotAPI
 }
@@ -2245,6 +2221,7 @@ func (b *PhotoMessageBuilder) WithParseMode(mode string) *PhotoMessageBuilder {
 
 // PollMessageBuilder 投票消息构建器
 type PollMessageBuilder struct {
+	MessageOptions[*PollMessageBuilder]
 	Msg *tgbotapi.SendPollConfig
 	bot *tgbotapi.BotAPI
 }
@@ -2291,50 +2268,165 @@ func (c *Context) CopyTo(chatID int64) (tgbotapi.Message, error) {
 	return c.Bot.Send(msg)
 }
 
-// MediaGroupBuilder 相册/媒体组发送
+// MediaGroupBuilder 相册/媒体组发送。
+// 媒体组包含 2 到 10 个文件：照片与视频可以混合，文档、音频只能与同类型的文件组合。
+// tgbotapi v5.5.1 的 MediaGroupConfig 不支持 allow_sending_without_reply，设置 WithAllowSendingWithoutReply 时 Send 返回错误。
 type MediaGroupBuilder struct {
-	ChatID int64
-	Media  []interface{}
-	bot    *tgbotapi.BotAPI
+	MessageOptions[*MediaGroupBuilder]
+	ChatID    int64
+	Media     []interface{}
+	bot       *tgbotapi.BotAPI
+	base      tgbotapi.BaseChat // 通用发送选项，发送时写入 MediaGroupConfig
+	caption   string            // 第一个文件的说明文字
+	parseMode string
 }
 
 // ReplyWithMediaGroup 构建媒体组
+//
+// 示例:
+//
+//	msgs, err := c.ReplyWithMediaGroup().
+//	    AddPhoto(tgbotapi.FileID(id1)).
+//	    AddPhoto(tgbotapi.FileURL(url)).
+//	    WithCaption("相册").
+//	    Send()
 func (c *Context) ReplyWithMediaGroup() *MediaGroupBuilder {
 	if c.Message == nil {
 		return nil
 	}
-	return &MediaGroupBuilder{ChatID: c.Message.Chat.ID, bot: c.Bot}
+	return newMediaGroupBuilder(c.Message.Chat.ID, c.Bot)
 }
 
+// Add 添加 tgbotapi.InputMediaPhoto、InputMediaVideo、InputMediaDocument 或 InputMediaAudio，其他类型在 Send 时返回错误
 func (b *MediaGroupBuilder) Add(media interface{}) *MediaGroupBuilder {
 	b.Media = append(b.Media, media)
 	return b
 }
 
+// AddPhoto 添加照片，file 可以是 tgbotapi.FileID、FileURL、FileBytes、FilePath 或 FileReader
+func (b *MediaGroupBuilder) AddPhoto(file tgbotapi.RequestFileData) *MediaGroupBuilder {
+	return b.Add(tgbotapi.NewInputMediaPhoto(file))
+}
+
+// AddVideo 添加视频
+func (b *MediaGroupBuilder) AddVideo(file tgbotapi.RequestFileData) *MediaGroupBuilder {
+	return b.Add(tgbotapi.NewInputMediaVideo(file))
+}
+
+// AddDocument 添加文档
+func (b *MediaGroupBuilder) AddDocument(file tgbotapi.RequestFileData) *MediaGroupBuilder {
+	return b.Add(tgbotapi.NewInputMediaDocument(file))
+}
+
+// AddAudio 添加音频
+func (b *MediaGroupBuilder) AddAudio(file tgbotapi.RequestFileData) *MediaGroupBuilder {
+	return b.Add(tgbotapi.NewInputMediaAudio(file))
+}
+
+// WithCaption 设置媒体组的说明文字，显示在第一个文件上
+func (b *MediaGroupBuilder) WithCaption(caption string) *MediaGroupBuilder {
+	b.caption = caption
+	return b
+}
+
+// WithParseMode 设置说明文字的解析模式
+func (b *MediaGroupBuilder) WithParseMode(mode string) *MediaGroupBuilder {
+	b.parseMode = mode
+	return b
+}
+
+// ErrInvalidMediaGroup 媒体组的文件数量或类型组合不被 Telegram 接受
+var ErrInvalidMediaGroup = errors.New("invalid media group")
+
+// mediaGroupItemType 返回媒体组文件的类型
+func mediaGroupItemType(media interface{}) (string, bool) {
+	switch media.(type) {
+	case tgbotapi.InputMediaPhoto:
+		return "photo", true
+	case tgbotapi.InputMediaVideo:
+		return "video", true
+	case tgbotapi.InputMediaDocument:
+		return "document", true
+	case tgbotapi.InputMediaAudio:
+		return "audio", true
+	}
+	return "", false
+}
+
+// validate 检查文件数量与类型组合
+func (b *MediaGroupBuilder) validate() error {
+	if n := len(b.Media); n < 2 || n > 10 {
+		return fmt.Errorf("%w: must contain 2-10 items, got %d", ErrInvalidMediaGroup, n)
+	}
+	var first string
+	for i, media := range b.Media {
+		typ, ok := mediaGroupItemType(media)
+		if !ok {
+			return fmt.Errorf("%w: unsupported item %d of type %T", ErrInvalidMediaGroup, i, media)
+		}
+		if i == 0 {
+			first = typ
+			continue
+		}
+		visual := (typ == "photo" || typ == "video") && (first == "photo" || first == "video")
+		if typ != first && !visual {
+			return fmt.Errorf("%w: cannot mix %s and %s", ErrInvalidMediaGroup, first, typ)
+		}
+	}
+	return nil
+}
+
+// withCaption 返回第一个文件带有说明文字的媒体列表，不修改 b.Media
+func (b *MediaGroupBuilder) withCaption() []interface{} {
+	media := append([]interface{}(nil), b.Media...)
+	if b.caption == "" && b.parseMode == "" {
+		return media
+	}
+	switch m := media[0].(type) {
+	case tgbotapi.InputMediaPhoto:
+		m.Caption, m.ParseMode = b.caption, b.parseMode
+		media[0] = m
+	case tgbotapi.InputMediaVideo:
+		m.Caption, m.ParseMode = b.caption, b.parseMode
+		media[0] = m
+	case tgbotapi.InputMediaDocument:
+		m.Caption, m.ParseMode = b.caption, b.parseMode
+		media[0] = m
+	case tgbotapi.InputMediaAudio:
+		m.Caption, m.ParseMode = b.caption, b.parseMode
+		media[0] = m
+	}
+	return media
+}
+
+// Send 发送媒体组，返回发送成功的消息；文件数量或类型组合无效时返回 ErrInvalidMediaGroup
 func (b *MediaGroupBuilder) Send() ([]tgbotapi.Message, error) {
-	cfg := tgbotapi.MediaGroupConfig{ChatID: b.ChatID}
-	cfg.Media = b.Media
-	// 直接请求底层，因 SendMediaGroup 的 builder 在 v5 里使用 MediaGroupConfig
-	resp, err := b.bot.Request(cfg)
-	if err != nil {
+	if err := b.validate(); err != nil {
 		return nil, err
 	}
-	// 交由调用方解析；此处返回空切片以保持兼容，避免引入 json 解析
-	_ = resp
-	return []tgbotapi.Message{}, nil
+	if b.base.AllowSendingWithoutReply {
+		return nil, fmt.Errorf("media groups do not support AllowSendingWithoutReply")
+	}
+	cfg := tgbotapi.MediaGroupConfig{ChatID: b.ChatID}
+	cfg.Media = b.withCaption()
+	cfg.DisableNotification = b.base.DisableNotification
+	cfg.ReplyToMessageID = b.base.ReplyToMessageID
+	return b.bot.SendMediaGroup(cfg)
 }
 
 // SendChatAction 发送聊天动作（typing 等）
+// 适用于所有属于聊天的更新，否则返回 ErrNoChat
 func (c *Context) SendChatAction(action string) error {
-	if c.Message == nil {
-		return fmt.Errorf("no message context for chat action")
+	chat, err := c.Chat()
+	if err != nil {
+		return err
 	}
-	_, err := c.Bot.Request(tgbotapi.NewChatAction(c.Message.Chat.ID, action))
-	return err
+	return c.SendTo(chat.ID).ChatAction(action)
 }
 
 // InvoiceBuilder 支付发票（简化版）
 type InvoiceBuilder struct {
+	MessageOptions[*InvoiceBuilder]
 	Msg *tgbotapi.InvoiceConfig
 	bot *tgbotapi.BotAPI
 }
@@ -2344,7 +2436,7 @@ func (c *Context) SendInvoice() *InvoiceBuilder {
 		return nil
 	}
 	msg := tgbotapi.InvoiceConfig{BaseChat: tgbotapi.BaseChat{ChatID: c.Message.Chat.ID}}
-	return &InvoiceBuilder{Msg: &msg, bot: c.Bot}
+	return newInvoiceBuilder(&msg, c.Bot)
 }
 
 func (b *InvoiceBuilder) Send() (tgbotapi.Message, error) {
@@ -2353,6 +2445,7 @@ func (b *InvoiceBuilder) Send() (tgbotapi.Message, error) {
 
 // LocationMessageBuilder 位置消息构建器
 type LocationMessageBuilder struct {
+	MessageOptions[*LocationMessageBuilder]
 	Msg *tgbotapi.LocationConfig
 	bot *tgbotapi.BotAPI
 }
@@ -2373,6 +2466,7 @@ func (b *LocationMessageBuilder) WithInlineKeyboard(markup tgbotapi.InlineKeyboa
 
 // VenueMessageBuilder 地点消息构建器
 type VenueMessageBuilder struct {
+	MessageOptions[*VenueMessageBuilder]
 	Msg *tgbotapi.VenueConfig
 	bot *tgbotapi.BotAPI
 }
@@ -2393,6 +2487,7 @@ func (b *VenueMessageBuilder) WithInlineKeyboard(markup tgbotapi.InlineKeyboardM
 
 // ContactMessageBuilder 联系人消息构建器
 type ContactMessageBuilder struct {
+	MessageOptions[*ContactMessageBuilder]
 	Msg *tgbotapi.ContactConfig
 	bot *tgbotapi.BotAPI
 }
@@ -2413,6 +2508,7 @@ func (b *ContactMessageBuilder) WithInlineKeyboard(markup tgbotapi.InlineKeyboar
 
 // DocumentMessageBuilder 文档消息构建器
 type DocumentMessageBuilder struct {
+	MessageOptions[*DocumentMessageBuilder]
 	Msg *tgbotapi.DocumentConfig
 	bot *tgbotapi.BotAPI
 }
@@ -2443,6 +2539,7 @@ func (b *DocumentMessageBuilder) WithParseMode(mode string) *DocumentMessageBuil
 
 // AudioMessageBuilder 音频消息构建器
 type AudioMessageBuilder struct {
+	MessageOptions[*AudioMessageBuilder]
 	Msg *tgbotapi.AudioConfig
 	bot *tgbotapi.BotAPI
 }
@@ -2488,6 +2585,7 @@ func (b *AudioMessageBuilder) WithPerformer(performer string) *AudioMessageBuild
 
 // VideoMessageBuilder 视频消息构建器
 type VideoMessageBuilder struct {
+	MessageOptions[*VideoMessageBuilder]
 	Msg *tgbotapi.VideoConfig
 	bot *tgbotapi.BotAPI
 }
@@ -2528,6 +2626,7 @@ func (b *VideoMessageBuilder) WithSupportsStreaming(supportsStreaming bool) *Vid
 
 // VoiceMessageBuilder 语音消息构建器
 type VoiceMessageBuilder struct {
+	MessageOptions[*VoiceMessageBuilder]
 	Msg *tgbotapi.VoiceConfig
 	bot *tgbotapi.BotAPI
 }
@@ -2563,6 +2662,7 @@ func (b *VoiceMessageBuilder) WithDuration(duration int) *VoiceMessageBuilder {
 
 // VideoNoteMessageBuilder 视频笔记消息构建器
 type VideoNoteMessageBuilder struct {
+	MessageOptions[*VideoNoteMessageBuilder]
 	Msg *tgbotapi.VideoNoteConfig
 	bot *tgbotapi.BotAPI
 }
@@ -2593,6 +2693,7 @@ func (b *VideoNoteMessageBuilder) WithLength(length int) *VideoNoteMessageBuilde
 
 // StickerMessageBuilder 贴纸消息构建器
 type StickerMessageBuilder struct {
+	MessageOptions[*StickerMessageBuilder]
 	Msg *tgbotapi.StickerConfig
 	bot *tgbotapi.BotAPI
 }
@@ -2613,6 +2714,7 @@ func (b *StickerMessageBuilder) WithInlineKeyboard(markup tgbotapi.InlineKeyboar
 
 // AnimationMessageBuilder 动画消息构建器
 type AnimationMessageBuilder struct {
+	MessageOptions[*AnimationMessageBuilder]
 	Msg *tgbotapi.AnimationConfig
 	bot *tgbotapi.BotAPI
 }
@@ -2704,6 +2806,7 @@ func (r *TelegramRouter) HandleWebhookRequest(w http.ResponseWriter, req *http.R
 // NewWebhookServer 基于自定义 mux 构造 *http.Server（不启动）
 // 遵循“不强占默认 ServeMux”的注意事项
 func (t *TelegramRouter) NewWebhookServer(listenAddr, path string) *http.Server {
+	t.checkRoutes()
 	if path == "" {
 		path = "/bot"
 	}
@@ -2714,6 +2817,7 @@ func (t *TelegramRouter) NewWebhookServer(listenAddr, path string) *http.Server
 
 // AttachToServer 将处理函数挂载到外部 *http.Server（不启动）
 func (t *TelegramRouter) AttachToServer(srv *http.Server, path string) {
+	t.checkRoutes()
 	if srv == nil {
 		return
 	}
@@ -2737,6 +2841,7 @@ const defaultQueueSize = 1024
 // 默认队列大小为 1024；如果需要自定义可以改此实现或添加参数。
 // 默认并发度为 8；如果需要自定义可以改此实现或添加参数。
 func (r *TelegramRouter) ListenWithContext(ctx context.Context, workers int, queueSize int) {
+	r.checkRoutes()
 	if workers <= 0 {
 		workers = 8
 	}
@@ -2833,10 +2938,13 @@ func (r *TelegramRouter) ListenWithContext(ctx context.Context, workers int, que
 	<-produceDone
 	close(jobs)
 	wg.Wait()
+	// 处理尚未到期的媒体组
+	r.FlushMediaGroups()
 }
 
 // Listen 使用长轮询方式启动机器人
 func (r *TelegramRouter) Listen() {
+	r.checkRoutes()
 	updates := r.Bot.GetUpdatesChan(tgbotapi.UpdateConfig{Offset: 0, Timeout: 60})
 	for update := range updates {
 		u := update
@@ -2846,6 +2954,7 @@ func (r *TelegramRouter) Listen() {
 
 // Handler 返回 http.Handler，便于集成外部 mux
 func (t *TelegramRouter) Handler() http.Handler {
+	t.checkRoutes()
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		t.HandleWebhookRequest(w, r)
 	})
@@ -2853,6 +2962,7 @@ func (t *TelegramRouter) Handler() http.Handler {
 
 // HandleFunc 返回 http.HandlerFunc
 func (t *TelegramRouter) HandleFunc() http.HandlerFunc {
+	t.checkRoutes()
 	return func(w http.ResponseWriter, r *http.Request) {
 		t.HandleWebhookRequest(w, r)
 	}
@@ -2860,35 +2970,33 @@ func (t *TelegramRouter) HandleFunc() http.HandlerFunc {
 
 // TextMatch 注册文本匹配处理器
 // 当文本消息匹配指定模式时触发
-func (t *TelegramRouter) TextMatch(pattern string, handler HandlerFunc) {
-	t.Text(func(c *Context) {
-		if strings.HasPrefix(c.Message.Text, pattern) {
-			handler(c)
-		}
-	})
+func (g *RouterGroup) TextMatch(pattern string, handler HandlerFunc) *RouteHandle {
+	return g.addMatch("textMatch", pattern, &g.router.textHandlers, func(c *Context) bool {
+		return strings.HasPrefix(c.Message.Text, pattern)
+	}, handler)
 }
 
 // TextRegex 注册正则表达式文本处理器
 // 当文本消息匹配正则表达式时触发
-func (t *TelegramRouter) TextRegex(regex *regexp.Regexp, handler HandlerFunc) {
-	t.Text(func(c *Context) {
-		if regex.MatchString(c.Message.Text) {
-			handler(c)
-		}
-	})
+func (g *RouterGroup) TextRegex(regex *regexp.Regexp, handler HandlerFunc) *RouteHandle {
+	return g.addMatch("textRegex", regex.String(), &g.router.textHandlers, func(c *Context) bool {
+		return regex.MatchString(c.Message.Text)
+	}, handler)
 }
 
 // CommandRegex 注册正则表达式命令处理器
 // 当命令匹配正则表达式时触发
-func (t *TelegramRouter) CommandRegex(regex *regexp.Regexp, handlers ...HandlerFunc) {
+func (g *RouterGroup) CommandRegex(regex *regexp.Regexp, handlers ...HandlerFunc) *RouteHandle {
 	if regex == nil {
-		return
+		return nil
 	}
-	t.commandRegexRoutes = append(t.commandRegexRoutes, &CommandRegexRoute{
-		regex:    regex,
-		handlers: handlers,
-	})
+	t := g.router
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	r := &route{kind: "commandRegex", pattern: regex.String(), group: g, handlers: handlers}
+	t.commandRegexRoutes = append(t.commandRegexRoutes, &CommandRegexRoute{route: r, regex: regex})
 	t.composedDirty = true
+	return t.register(r, removeFunc(&t.commandRegexRoutes, func(cr *CommandRegexRoute) bool { return cr.route == r }))
 }
 
 // parseRouteParams 解析路由参数
@@ -2904,26 +3012,6 @@ func parseRouteParams(pattern string) []string {
 	return params
 }
 
-// compileRoutePattern 编译路由模式为正则表达式
-// 支持以下格式：
-// - 静态路径：如 "menu/main"
-// - 参数路径：如 "user/:id/profile"
-// - 通配符：如 "action/*"
-func compileRoutePattern(pattern string) *regexp.Regexp {
-	parts := strings.Split(pattern, "/")
-	for i, part := range parts {
-		if strings.HasPrefix(part, ":") {
-			// 参数匹配，如 :id
-			parts[i] = "([^/]+)"
-		} else if part == "*" {
-			// 通配符匹配
-			parts[i] = ".*"
-		}
-	}
-	regexPattern := "^" + strings.Join(parts, "/") + "$"
-	return regexp.MustCompile(regexPattern)
-}
-
 // ReplyWithLocation 创建位置消息构建器
 func (c *Context) ReplyWithLocation(latitude, longitude float64) *LocationMessageBuilder {
 	if c.Message == nil {
@@ -2931,10 +3019,7 @@ func (c *Context) ReplyWithLocation(latitude, longitude float64) *LocationMessag
 	}
 	msg := tgbotapi.NewLocation(c.Message.Chat.ID, latitude, longitude)
 	msg.ReplyToMessageID = c.Message.MessageID
-	return &LocationMessageBuilder{
-		Msg: &msg,
-		bot: c.Bot,
-	}
+	return newLocationMessageBuilder(&msg, c.Bot)
 }
 
 // ReplyWithVenue 创建地点消息构建器
@@ -2944,10 +3029,7 @@ func (c *Context) ReplyWithVenue(latitude, longitude float64, title, address str
 	}
 	msg := tgbotapi.NewVenue(c.Message.Chat.ID, title, address, latitude, longitude)
 	msg.ReplyToMessageID = c.Message.MessageID
-	return &VenueMessageBuilder{
-		Msg: &msg,
-		bot: c.Bot,
-	}
+	return newVenueMessageBuilder(&msg, c.Bot)
 }
 
 // ReplyWithContact 创建联系人消息构建器
@@ -2960,10 +3042,7 @@ func (c *Context) ReplyWithContact(phoneNumber, firstName string, lastName ...st
 		msg.LastName = lastName[0]
 	}
 	msg.ReplyToMessageID = c.Message.MessageID
-	return &ContactMessageBuilder{
-		Msg: &msg,
-		bot: c.Bot,
-	}
+	return newContactMessageBuilder(&msg, c.Bot)
 }
 
 // ReplyWithPoll 创建投票消息构建器
@@ -2975,10 +3054,7 @@ func (c *Context) ReplyWithPoll(question string, options []string, isAnonymous b
 	msg.ReplyToMessageID = c.Message.MessageID
 	msg.IsAnonymous = isAnonymous
 	msg.Type = pollType
-	return &PollMessageBuilder{
-		Msg: &msg,
-		bot: c.Bot,
-	}
+	return newPollMessageBuilder(&msg, c.Bot)
 }
 
 // ReplyWithQuiz 创建测验消息构建器
@@ -2990,122 +3066,103 @@ func (c *Context) ReplyWithQuiz(question string, options []string, correctOption
 	msg.ReplyToMessageID = c.Message.MessageID
 	msg.Type = "quiz"
 	msg.CorrectOptionID = correctOptionID
-	return &PollMessageBuilder{
-		Msg: &msg,
-		bot: c.Bot,
-	}
+	return newPollMessageBuilder(&msg, c.Bot)
 }
 
 // OnGroupChatCreated 注册群组聊天创建处理函数。
 // 可以一次注册多个处理函数，它们会按顺序执行，直到被中断。
-func (t *TelegramRouter) OnGroupChatCreated(handlers ...HandlerFunc) {
-	t.groupChatCreatedHandlers = append(t.groupChatCreatedHandlers, handlers...)
-	t.composedDirty = true
+func (g *RouterGroup) OnGroupChatCreated(handlers ...HandlerFunc) *RouteHandle {
+	return g.add("groupChatCreated", &g.router.groupChatCreatedHandlers, handlers)
 }
 
 // OnSupergroupChatCreated 注册超级群组聊天创建处理函数。
 // 可以一次注册多个处理函数，它们会按顺序执行，直到被中断。
-func (t *TelegramRouter) OnSupergroupChatCreated(handlers ...HandlerFunc) {
-	t.supergroupChatCreatedHandlers = append(t.supergroupChatCreatedHandlers, handlers...)
-	t.composedDirty = true
+func (g *RouterGroup) OnSupergroupChatCreated(handlers ...HandlerFunc) *RouteHandle {
+	return g.add("supergroupChatCreated", &g.router.supergroupChatCreatedHandlers, handlers)
 }
 
 // OnChannelChatCreated 注册频道聊天创建处理函数。
 // 可以一次注册多个处理函数，它们会按顺序执行，直到被中断。
-func (t *TelegramRouter) OnChannelChatCreated(handlers ...HandlerFunc) {
-	t.channelChatCreatedHandlers = append(t.channelChatCreatedHandlers, handlers...)
-	t.composedDirty = true
+func (g *RouterGroup) OnChannelChatCreated(handlers ...HandlerFunc) *RouteHandle {
+	return g.add("channelChatCreated", &g.router.channelChatCreatedHandlers, handlers)
 }
 
 // OnNewChatMembers 注册新聊天成员处理函数。
 // 可以一次注册多个处理函数，它们会按顺序执行，直到被中断。
-func (t *TelegramRouter) OnNewChatMembers(handlers ...HandlerFunc) {
-	t.newChatMembersHandlers = append(t.newChatMembersHandlers, handlers...)
-	t.composedDirty = true
+func (g *RouterGroup) OnNewChatMembers(handlers ...HandlerFunc) *RouteHandle {
+	return g.add("newChatMembers", &g.router.newChatMembersHandlers, handlers)
 }
 
 // OnLeftChatMember 注册离开聊天成员处理函数。
 // 可以一次注册多个处理函数，它们会按顺序执行，直到被中断。
-func (t *TelegramRouter) OnLeftChatMember(handlers ...HandlerFunc) {
-	t.leftChatMemberHandlers = append(t.leftChatMemberHandlers, handlers...)
-	t.composedDirty = true
+func (g *RouterGroup) OnLeftChatMember(handlers ...HandlerFunc) *RouteHandle {
+	return g.add("leftChatMember", &g.router.leftChatMemberHandlers, handlers)
 }
 
 // OnNewChatTitle 注册新聊天标题处理函数。
 // 可以一次注册多个处理函数，它们会按顺序执行，直到被中断。
-func (t *TelegramRouter) OnNewChatTitle(handlers ...HandlerFunc) {
-	t.newChatTitleHandlers = append(t.newChatTitleHandlers, handlers...)
-	t.composedDirty = true
+func (g *RouterGroup) OnNewChatTitle(handlers ...HandlerFunc) *RouteHandle {
+	return g.add("newChatTitle", &g.router.newChatTitleHandlers, handlers)
 }
 
 // OnNewChatPhoto 注册新聊天照片处理函数。
 // 可以一次注册多个处理函数，它们会按顺序执行，直到被中断。
-func (t *TelegramRouter) OnNewChatPhoto(handlers ...HandlerFunc) {
-	t.newChatPhotoHandlers = append(t.newChatPhotoHandlers, handlers...)
-	t.composedDirty = true
+func (g *RouterGroup) OnNewChatPhoto(handlers ...HandlerFunc) *RouteHandle {
+	return g.add("newChatPhoto", &g.router.newChatPhotoHandlers, handlers)
 }
 
 // OnDeleteChatPhoto 注册删除聊天照片处理函数。
 // 可以一次注册多个处理函数，它们会按顺序执行，直到被中断。
-func (t *TelegramRouter) OnDeleteChatPhoto(handlers ...HandlerFunc) {
-	t.deleteChatPhotoHandlers = append(t.deleteChatPhotoHandlers, handlers...)
-	t.composedDirty = true
+func (g *RouterGroup) OnDeleteChatPhoto(handlers ...HandlerFunc) *RouteHandle {
+	return g.add("deleteChatPhoto", &g.router.deleteChatPhotoHandlers, handlers)
 }
 
 // OnEditedMessage 注册编辑后的消息处理函数。
 // 可以一次注册多个处理函数，它们会按顺序执行，直到被中断。
-func (t *TelegramRouter) OnEditedMessage(handlers ...HandlerFunc) {
-	t.editedMessageHandlers = append(t.editedMessageHandlers, handlers...)
-	t.composedDirty = true
+func (g *RouterGroup) OnEditedMessage(handlers ...HandlerFunc) *RouteHandle {
+	return g.add("editedMessage", &g.router.editedMessageHandlers, handlers)
 }
 
 // OnEditedChannelPost 注册编辑后的频道消息处理函数。
 // 可以一次注册多个处理函数，它们会按顺序执行，直到被中断。
-func (t *TelegramRouter) OnEditedChannelPost(handlers ...HandlerFunc) {
-	t.editedChannelPostHandlers = append(t.editedChannelPostHandlers, handlers...)
-	t.composedDirty = true
+func (g *RouterGroup) OnEditedChannelPost(handlers ...HandlerFunc) *RouteHandle {
+	return g.add("editedChannelPost", &g.router.editedChannelPostHandlers, handlers)
 }
 
 // OnMyChatMember 注册我的聊天成员更新处理函数。
 // 可以一次注册多个处理函数，它们会按顺序执行，直到被中断。
-func (t *TelegramRouter) OnMyChatMember(handlers ...HandlerFunc) {
-	t.myChatMemberHandlers = append(t.myChatMemberHandlers, handlers...)
-	t.composedDirty = true
+func (g *RouterGroup) OnMyChatMember(handlers ...HandlerFunc) *RouteHandle {
+	return g.add("myChatMember", &g.router.myChatMemberHandlers, handlers)
 }
 
 // OnChatMember 注册聊天成员更新处理函数。
 // 可以一次注册多个处理函数，它们会按顺序执行，直到被中断。
-func (t *TelegramRouter) OnChatMember(handlers ...HandlerFunc) {
-	t.chatMemberHandlers = append(t.chatMemberHandlers, handlers...)
-	t.composedDirty = true
+func (g *RouterGroup) OnChatMember(handlers ...HandlerFunc) *RouteHandle {
+	return g.add("chatMember", &g.router.chatMemberHandlers, handlers)
 }
 
 // OnPollAnswer 注册投票答案处理函数。
 // 可以一次注册多个处理函数，它们会按顺序执行，直到被中断。
-func (t *TelegramRouter) OnPollAnswer(handlers ...HandlerFunc) {
-	t.pollAnswerHandlers = append(t.pollAnswerHandlers, handlers...)
-	t.composedDirty = true
+func (g *RouterGroup) OnPollAnswer(handlers ...HandlerFunc) *RouteHandle {
+	return g.add("pollAnswer", &g.router.pollAnswerHandlers, handlers)
 }
 
 // OnPreCheckoutQuery 注册预结账查询处理函数。
 // 可以一次注册多个处理函数，它们会按顺序执行，直到被中断。
-func (t *TelegramRouter) OnPreCheckoutQuery(handlers ...HandlerFunc) {
-	t.preCheckoutQueryHandlers = append(t.preCheckoutQueryHandlers, handlers...)
-	t.composedDirty = true
+func (g *RouterGroup) OnPreCheckoutQuery(handlers ...HandlerFunc) *RouteHandle {
+	return g.add("preCheckoutQuery", &g.router.preCheckoutQueryHandlers, handlers)
 }
 
 // OnShippingQuery 注册运费查询处理函数。
 // 可以一次注册多个处理函数，它们会按顺序执行，直到被中断。
-func (t *TelegramRouter) OnShippingQuery(handlers ...HandlerFunc) {
-	t.shippingQueryHandlers = append(t.shippingQueryHandlers, handlers...)
-	t.composedDirty = true
+func (g *RouterGroup) OnShippingQuery(handlers ...HandlerFunc) *RouteHandle {
+	return g.add("shippingQuery", &g.router.shippingQueryHandlers, handlers)
 }
 
 // OnSuccessfulPayment 注册成功支付处理函数。
 // 可以一次注册多个处理函数，它们会按顺序执行，直到被中断。
-func (t *TelegramRouter) OnSuccessfulPayment(handlers ...HandlerFunc) {
-	t.successfulPaymentHandlers = append(t.successfulPaymentHandlers, handlers...)
-	t.composedDirty = true
+func (g *RouterGroup) OnSuccessfulPayment(handlers ...HandlerFunc) *RouteHandle {
+	return g.add("successfulPayment", &g.router.successfulPaymentHandlers, handlers)
 }
 
 // OnUpdate 注册通用更新处理函数
@@ -3115,39 +3172,19 @@ func (t *TelegramRouter) OnSuccessfulPayment(handlers ...HandlerFunc) {
 // - 所有类型的回调查询
 // - 所有类型的频道消息
 // - 所有类型的支付相关更新
-func (t *TelegramRouter) OnUpdate(handlers ...HandlerFunc) {
-	t.mu.Lock()
-	t.updateHandlers = append(t.updateHandlers, func(c *Context) {
-		// 读取中间件快照，避免运行时并发修改
-		t.mu.RLock()
-		mws := make([]HandlerFunc, len(t.middlewares))
-		copy(mws, t.middlewares)
-		t.mu.RUnlock()
-		chain := make([]HandlerFunc, 0, len(mws)+len(handlers))
-		chain = append(chain, mws...)
-		chain = append(chain, handlers...)
-		c.handlers = chain
-		c.index = -1
-		c.Next()
-	})
-	t.mu.Unlock()
+func (g *RouterGroup) OnUpdate(handlers ...HandlerFunc) *RouteHandle {
+	return g.add("update", &g.router.updateHandlers, handlers)
 }
 
 // Inline 注册与分发
 // OnInlineQuery 注册 InlineQuery 处理器
-func (t *TelegramRouter) OnInlineQuery(handlers ...HandlerFunc) {
-	t.mu.Lock()
-	t.inlineQueryHandlers = append(t.inlineQueryHandlers, handlers...)
-	t.composedDirty = true
-	t.mu.Unlock()
+func (g *RouterGroup) OnInlineQuery(handlers ...HandlerFunc) *RouteHandle {
+	return g.add("inlineQuery", &g.router.inlineQueryHandlers, handlers)
 }
 
 // OnChosenInlineResult 注册 ChosenInlineResult 处理器
-func (t *TelegramRouter) OnChosenInlineResult(handlers ...HandlerFunc) {
-	t.mu.Lock()
-	t.chosenInlineResultHandlers = append(t.chosenInlineResultHandlers, handlers...)
-	t.composedDirty = true
-	t.mu.Unlock()
+func (g *RouterGroup) OnChosenInlineResult(handlers ...HandlerFunc) *RouteHandle {
+	return g.add("chosenInlineResult", &g.router.chosenInlineResultHandlers, handlers)
 }
 
 // InlineAnswerBuilder 用于回答 inline query
@@ -0,0 +1,288 @@
+package tgr
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// testChat 测试更新使用的私聊
+func testChat() *tgbotapi.Chat {
+	return &tgbotapi.Chat{ID: 1, Type: "private"}
+}
+
+// callbackUpdate 构造回调查询
+func callbackUpdate(data string) *tgbotapi.Update {
+	return &tgbotapi.Update{CallbackQuery: &tgbotapi.CallbackQuery{
+		Data:    data,
+		From:    &tgbotapi.User{ID: 7},
+		Message: &tgbotapi.Message{Chat: testChat()},
+	}}
+}
+
+// textUpdate 构造文本消息
+func textUpdate(text string) *tgbotapi.Update {
+	return &tgbotapi.Update{Message: &tgbotapi.Message{Text: text, Chat: testChat(), From: &tgbotapi.User{ID: 7}}}
+}
+
+// commandUpdate 构造命令消息，bot_command 实体只覆盖命令本身（到第一个空格为止）
+func commandUpdate(text string) *tgbotapi.Update {
+	cmd, _, _ := strings.Cut(text, " ")
+	return &tgbotapi.Update{Message: &tgbotapi.Message{
+		Text:     text,
+		Chat:     testChat(),
+		From:     &tgbotapi.User{ID: 7},
+		Entities: []tgbotapi.MessageEntity{{Type: "bot_command", Offset: 0, Length: len(cmd)}},
+	}}
+}
+
+// recorder 记录处理函数的执行顺序
+type recorder struct {
+	log []string
+}
+
+func (r *recorder) handler(name string) HandlerFunc {
+	return func(c *Context) { r.log = append(r.log, name) }
+}
+
+// fakeAPI 记录 Bot API 请求并返回预设结果的 HTTP 客户端
+type fakeAPI struct {
+	mu       sync.Mutex
+	requests []fakeRequest
+	queued   map[string][]string // 方法名到依次返回一次的 result，用完后使用 results
+	results  map[string]string   // 方法名到 result 的 JSON；未设置时 send* 返回一条消息，其他方法返回 true，为空时返回错误
+}
+
+// fakeRequest 一次 Bot API 请求
+type fakeRequest struct {
+	method string
+	params url.Values
+}
+
+// newFakeBot 创建使用 fakeAPI 的 BotAPI
+func newFakeBot() (*tgbotapi.BotAPI, *fakeAPI) {
+	api := &fakeAPI{
+		queued:  map[string][]string{},
+		results: map[string]string{"getMe": `{"id":100,"is_bot":true,"first_name":"Test","username":"test_bot"}`, "getUpdates": "[]"},
+	}
+	bot, err := tgbotapi.NewBotAPIWithClient("test", tgbotapi.APIEndpoint, api)
+	if err != nil {
+		panic(err)
+	}
+	api.requests = nil
+	return bot, api
+}
+
+func (f *fakeAPI) Do(req *http.Request) (*http.Response, error) {
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		return nil, err
+	}
+	params, _ := url.ParseQuery(string(body))
+	method := req.URL.Path[strings.LastIndex(req.URL.Path, "/")+1:]
+
+	f.mu.Lock()
+	f.requests = append(f.requests, fakeRequest{method: method, params: params})
+	result, ok := f.results[method]
+	if q := f.queued[method]; len(q) > 0 {
+		result, ok, f.queued[method] = q[0], true, q[1:]
+	}
+	f.mu.Unlock()
+	switch {
+	case !ok && strings.HasPrefix(method, "send"):
+		result = `{"message_id":1,"date":0,"chat":{"id":1,"type":"private"}}`
+	case !ok:
+		result = "true"
+	}
+	resp := `{"ok":true,"result":` + result + `}`
+	if result == "" {
+		resp = `{"ok":false,"error_code":400,"description":"Bad Request"}`
+	}
+	return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(resp)), Header: http.Header{}}, nil
+}
+
+// calls 返回按顺序记录的请求
+func (f *fakeAPI) calls() []fakeRequest {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]fakeRequest(nil), f.requests...)
+}
+
+func TestMediaGroupBuilderValidate(t *testing.T) {
+	photo := tgbotapi.NewInputMediaPhoto(tgbotapi.FileID("p"))
+	video := tgbotapi.NewInputMediaVideo(tgbotapi.FileID("v"))
+	document := tgbotapi.NewInputMediaDocument(tgbotapi.FileID("d"))
+	audio := tgbotapi.NewInputMediaAudio(tgbotapi.FileID("a"))
+	repeat := func(item interface{}, n int) []interface{} {
+		items := make([]interface{}, n)
+		for i := range items {
+			items[i] = item
+		}
+		return items
+	}
+	tests := []struct {
+		name    string
+		items   []interface{}
+		wantErr bool
+	}{
+		{name: "empty", wantErr: true},
+		{name: "single item", items: repeat(photo, 1), wantErr: true},
+		{name: "two photos", items: repeat(photo, 2)},
+		{name: "ten photos", items: repeat(photo, 10)},
+		{name: "eleven photos", items: repeat(photo, 11), wantErr: true},
+		{name: "photo and video", items: []interface{}{photo, video, photo}},
+		{name: "video and photo", items: []interface{}{video, photo}},
+		{name: "documents", items: []interface{}{document, document}},
+		{name: "audios", items: []interface{}{audio, audio}},
+		{name: "photo and document", items: []interface{}{photo, document}, wantErr: true},
+		{name: "document and photo", items: []interface{}{document, photo}, wantErr: true},
+		{name: "video and audio", items: []interface{}{video, audio}, wantErr: true},
+		{name: "audio and document", items: []interface{}{audio, document}, wantErr: true},
+		{name: "unsupported item", items: []interface{}{photo, "p"}, wantErr: true},
+		{name: "pointer item", items: []interface{}{photo, &photo}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newMediaGroupBuilder(1, nil)
+			for _, item := range tt.items {
+				b.Add(item)
+			}
+			err := b.validate()
+			if tt.wantErr != (err != nil) {
+				t.Fatalf("validate() = %v, want error %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrInvalidMediaGroup) {
+				t.Errorf("validate() = %v, want ErrInvalidMediaGroup", err)
+			}
+		})
+	}
+}
+
+func TestMediaGroupBuilderCaption(t *testing.T) {
+	tests := []struct {
+		name  string
+		build func(b *MediaGroupBuilder) *MediaGroupBuilder
+		want  []string // 每个文件的说明文字与解析模式
+	}{
+		{
+			name: "photos",
+			build: func(b *MediaGroupBuilder) *MediaGroupBuilder {
+				return b.AddPhoto(tgbotapi.FileID("a")).AddVideo(tgbotapi.FileID("b")).WithCaption("album").WithParseMode("HTML")
+			},
+			want: []string{"album/HTML", "/"},
+		},
+		{
+			name: "documents",
+			build: func(b *MediaGroupBuilder) *MediaGroupBuilder {
+				return b.AddDocument(tgbotapi.FileID("a")).AddDocument(tgbotapi.FileID("b")).WithCaption("files")
+			},
+			want: []string{"files/", "/"},
+		},
+		{
+			name: "audios",
+			build: func(b *MediaGroupBuilder) *MediaGroupBuilder {
+				return b.AddAudio(tgbotapi.FileID("a")).AddAudio(tgbotapi.FileID("b")).WithCaption("tracks")
+			},
+			want: []string{"tracks/", "/"},
+		},
+		{
+			name: "item caption kept without group caption",
+			build: func(b *MediaGroupBuilder) *MediaGroupBuilder {
+				photo := tgbotapi.NewInputMediaPhoto(tgbotapi.FileID("a"))
+				photo.Caption = "own"
+				return b.Add(photo).AddPhoto(tgbotapi.FileID("b"))
+			},
+			want: []string{"own/", "/"},
+		},
+	}
+	caption := func(media interface{}) string {
+		switch m := media.(type) {
+		case tgbotapi.InputMediaPhoto:
+			return m.Caption + "/" + m.ParseMode
+		case tgbotapi.InputMediaVideo:
+			return m.Caption + "/" + m.ParseMode
+		case tgbotapi.InputMediaDocument:
+			return m.Caption + "/" + m.ParseMode
+		case tgbotapi.InputMediaAudio:
+			return m.Caption + "/" + m.ParseMode
+		}
+		return ""
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := tt.build(newMediaGroupBuilder(1, nil))
+			before := make([]string, len(b.Media))
+			for i, m := range b.Media {
+				before[i] = caption(m)
+			}
+			var got []string
+			for _, m := range b.withCaption() {
+				got = append(got, caption(m))
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("captions %q, want %q", got, tt.want)
+			}
+			for i, m := range b.Media {
+				if caption(m) != before[i] {
+					t.Errorf("withCaption modified item %d", i)
+				}
+			}
+		})
+	}
+}
+
+func TestMediaGroupBuilderSend(t *testing.T) {
+	bot, api := newFakeBot()
+	api.results["sendMediaGroup"] = `[` +
+		`{"message_id":10,"media_group_id":"g","date":0,"chat":{"id":1,"type":"private"},"photo":[{"file_id":"a"}],"caption":"album"},` +
+		`{"message_id":11,"media_group_id":"g","date":0,"chat":{"id":1,"type":"private"},"photo":[{"file_id":"b"}]}]`
+	c := &Context{Update: textUpdate("hi"), Bot: bot}
+	c.Message.MessageID = 5
+
+	if _, err := c.ReplyWithMediaGroup().AddPhoto(tgbotapi.FileID("a")).AddDocument(tgbotapi.FileID("b")).Send(); !errors.Is(err, ErrInvalidMediaGroup) {
+		t.Fatalf("Send() = %v, want ErrInvalidMediaGroup", err)
+	}
+	if calls := api.calls(); len(calls) != 0 {
+		t.Fatalf("invalid group sent %d requests", len(calls))
+	}
+
+	msgs, err := c.ReplyWithMediaGroup().
+		AddPhoto(tgbotapi.FileID("a")).
+		AddPhoto(tgbotapi.FileID("b")).
+		WithCaption("album").
+		WithSilent().
+		WithReplyTo(5).
+		Send()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(msgs) != 2 || msgs[0].MessageID != 10 || msgs[1].MessageID != 11 || msgs[0].Caption != "album" {
+		t.Errorf("messages %+v", msgs)
+	}
+	calls := api.calls()
+	if len(calls) != 1 || calls[0].method != "sendMediaGroup" {
+		t.Fatalf("requests %+v", calls)
+	}
+	params := calls[0].params
+	if params.Get("chat_id") != "1" || params.Get("disable_notification") != "true" || params.Get("reply_to_message_id") != "5" {
+		t.Errorf("params %v", params)
+	}
+	var media []struct {
+		Type    string `json:"type"`
+		Caption string `json:"caption"`
+	}
+	if err := json.Unmarshal([]byte(params.Get("media")), &media); err != nil {
+		t.Fatal(err)
+	}
+	if len(media) != 2 || media[0].Caption != "album" || media[1].Caption != "" {
+		t.Errorf("media %+v, want caption on the first item only", media)
+	}
+}
@@ -0,0 +1,93 @@
+package tgr
+
+import (
+	"reflect"
+	"runtime"
+)
+
+// RouteInfo 已注册路由的描述信息，可直接序列化为 JSON，用于调试与管理工具
+type RouteInfo struct {
+	Kind        string   `json:"kind"`                  // 路由类型，如 "command"、"text"、"callback"
+	Pattern     string   `json:"pattern,omitempty"`     // 路由模式，如命令 "ban :user_id:int"、回调 "order/:id"
+	Handlers    []string `json:"handlers"`              // 处理函数名称
+	Middlewares []string `json:"middlewares,omitempty"` // 生效的中间件名称（全局中间件在前，由外到内）
+	State       string   `json:"state,omitempty"`       // 会话状态作用域
+	ChatTypes   []string `json:"chatTypes,omitempty"`   // 限定的聊天类型
+	Policy      string   `json:"policy,omitempty"`      // 分组单独设置的分发策略
+	Order       int      `json:"order"`                 // 注册顺序，从 1 开始
+}
+
+// register 为注册分配顺序号并登记到路由表，返回路由句柄；detach 从所属列表中移除该路由。
+// 调用方需持有 t.mu 写锁。
+func (t *TelegramRouter) register(r *route, detach func()) *RouteHandle {
+	t.routeOrder++
+	r.order = t.routeOrder
+	t.registry = append(t.registry, r)
+	return &RouteHandle{router: t, route: r, detach: detach}
+}
+
+// Routes 按注册顺序返回所有已注册的路由
+//
+// 示例:
+//
+//	for _, r := range router.Routes() {
+//	    log.Printf("%d %s %s -> %v", r.Order, r.Kind, r.Pattern, r.Handlers)
+//	}
+func (t *TelegramRouter) Routes() []RouteInfo {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	out := make([]RouteInfo, 0, len(t.registry))
+	for _, r := range t.registry {
+		out = append(out, t.routeInfo(r))
+	}
+	return out
+}
+
+// routeInfo 生成路由的描述信息，调用方需持有 t.mu
+func (t *TelegramRouter) routeInfo(r *route) RouteInfo {
+	info := RouteInfo{
+		Kind:     r.kind,
+		Pattern:  r.pattern,
+		Handlers: funcNames(r.handlers),
+		Order:    r.order,
+	}
+	info.Middlewares = append(funcNames(t.middlewares), funcNames(r.group.chain())...)
+	if len(info.Middlewares) == 0 {
+		info.Middlewares = nil
+	}
+	sc := r.group.scope()
+	if sc.hasState {
+		info.State = sc.state
+	}
+	if sc.chatTypes != 0 {
+		info.ChatTypes = sc.chatTypes.names()
+	}
+	for g := r.group; g != nil; g = g.parent {
+		if g.policy != DispatchDefault {
+			info.Policy = g.policy.String()
+			break
+		}
+	}
+	return info
+}
+
+// funcNames 返回函数名称列表
+func funcNames(handlers []HandlerFunc) []string {
+	names := make([]string, 0, len(handlers))
+	for _, h := range handlers {
+		names = append(names, funcName(h))
+	}
+	return names
+}
+
+// funcName 通过 runtime.FuncForPC 获取函数名称，如 "main.banHandler"、"main.main.func1"
+func funcName(f any) string {
+	v := reflect.ValueOf(f)
+	if v.Kind() != reflect.Func || v.IsNil() {
+		return ""
+	}
+	if fn := runtime.FuncForPC(v.Pointer()); fn != nil {
+		return fn.Name()
+	}
+	return ""
+}
@@ -0,0 +1,128 @@
+package tgr
+
+import tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+// ChatSender 向指定聊天发送消息，不依赖当前更新，可用于定时任务、HTTP 触发的通知与群发。
+// 创建的构建器与 Reply* 系列相同，但默认不回复任何消息。
+// 文件参数可以是 tgbotapi.FileID、FileURL、FileBytes、FilePath 或 FileReader。
+type ChatSender struct {
+	ChatID int64
+	bot    *tgbotapi.BotAPI
+}
+
+// SendTo 返回向指定聊天发送消息的 ChatSender
+//
+// 示例:
+//
+//	router.SendTo(chatID).Text("每日提醒").WithParseMode(tgbotapi.ModeHTML).Send()
+//	router.SendTo(chatID).Photo(tgbotapi.FileURL(url)).WithCaption("今日图片").Send()
+func (t *TelegramRouter) SendTo(chatID int64) *ChatSender {
+	return &ChatSender{ChatID: chatID, bot: t.Bot}
+}
+
+// SendTo 返回向指定聊天发送消息的 ChatSender，例如在处理函数中通知管理员群组
+func (c *Context) SendTo(chatID int64) *ChatSender {
+	return &ChatSender{ChatID: chatID, bot: c.Bot}
+}
+
+// Text 创建文本消息构建器
+func (s *ChatSender) Text(text string) *TextMessageBuilder {
+	msg := tgbotapi.NewMessage(s.ChatID, text)
+	return newTextMessageBuilder(&msg, s.bot)
+}
+
+// Photo 创建图片消息构建器
+func (s *ChatSender) Photo(file tgbotapi.RequestFileData) *PhotoMessageBuilder {
+	msg := tgbotapi.NewPhoto(s.ChatID, file)
+	return newPhotoMessageBuilder(&msg, s.bot)
+}
+
+// Document 创建文档消息构建器
+func (s *ChatSender) Document(file tgbotapi.RequestFileData) *DocumentMessageBuilder {
+	msg := tgbotapi.NewDocument(s.ChatID, file)
+	return newDocumentMessageBuilder(&msg, s.bot)
+}
+
+// Audio 创建音频消息构建器
+func (s *ChatSender) Audio(file tgbotapi.RequestFileData) *AudioMessageBuilder {
+	msg := tgbotapi.NewAudio(s.ChatID, file)
+	return newAudioMessageBuilder(&msg, s.bot)
+}
+
+// Video 创建视频消息构建器
+func (s *ChatSender) Video(file tgbotapi.RequestFileData) *VideoMessageBuilder {
+	msg := tgbotapi.NewVideo(s.ChatID, file)
+	return newVideoMessageBuilder(&msg, s.bot)
+}
+
+// Voice 创建语音消息构建器
+func (s *ChatSender) Voice(file tgbotapi.RequestFileData) *VoiceMessageBuilder {
+	msg := tgbotapi.NewVoice(s.ChatID, file)
+	return newVoiceMessageBuilder(&msg, s.bot)
+}
+
+// VideoNote 创建视频笔记消息构建器，length 为视频边长，0 表示由 Telegram 决定
+func (s *ChatSender) VideoNote(file tgbotapi.RequestFileData, length int) *VideoNoteMessageBuilder {
+	msg := tgbotapi.NewVideoNote(s.ChatID, length, file)
+	return newVideoNoteMessageBuilder(&msg, s.bot)
+}
+
+// Sticker 创建贴纸消息构建器
+func (s *ChatSender) Sticker(file tgbotapi.RequestFileData) *StickerMessageBuilder {
+	msg := tgbotapi.NewSticker(s.ChatID, file)
+	return newStickerMessageBuilder(&msg, s.bot)
+}
+
+// Animation 创建动画消息构建器
+func (s *ChatSender) Animation(file tgbotapi.RequestFileData) *AnimationMessageBuilder {
+	msg := tgbotapi.NewAnimation(s.ChatID, file)
+	return newAnimationMessageBuilder(&msg, s.bot)
+}
+
+// Location 创建位置消息构建器
+func (s *ChatSender) Location(latitude, longitude float64) *LocationMessageBuilder {
+	msg := tgbotapi.NewLocation(s.ChatID, latitude, longitude)
+	return newLocationMessageBuilder(&msg, s.bot)
+}
+
+// Venue 创建地点消息构建器
+func (s *ChatSender) Venue(latitude, longitude float64, title, address string) *VenueMessageBuilder {
+	msg := tgbotapi.NewVenue(s.ChatID, title, address, latitude, longitude)
+	return newVenueMessageBuilder(&msg, s.bot)
+}
+
+// Contact 创建联系人消息构建器
+func (s *ChatSender) Contact(phoneNumber, firstName string, lastName ...string) *ContactMessageBuilder {
+	msg := tgbotapi.NewContact(s.ChatID, phoneNumber, firstName)
+	if len(lastName) > 0 {
+		msg.LastName = lastName[0]
+	}
+	return newContactMessageBuilder(&msg, s.bot)
+}
+
+// Poll 创建投票消息构建器
+func (s *ChatSender) Poll(question string, options []string, isAnonymous bool, pollType string) *PollMessageBuilder {
+	msg := tgbotapi.NewPoll(s.ChatID, question, options...)
+	msg.IsAnonymous = isAnonymous
+	msg.Type = pollType
+	return newPollMessageBuilder(&msg, s.bot)
+}
+
+// Quiz 创建测验消息构建器
+func (s *ChatSender) Quiz(question string, options []string, correctOptionID int64) *PollMessageBuilder {
+	msg := tgbotapi.NewPoll(s.ChatID, question, options...)
+	msg.Type = "quiz"
+	msg.CorrectOptionID = correctOptionID
+	return newPollMessageBuilder(&msg, s.bot)
+}
+
+// MediaGroup 创建媒体组构建器
+func (s *ChatSender) MediaGroup() *MediaGroupBuilder {
+	return newMediaGroupBuilder(s.ChatID, s.bot)
+}
+
+// ChatAction 发送聊天动作（typing 等）
+func (s *ChatSender) ChatAction(action string) error {
+	_, err := s.bot.Request(tgbotapi.NewChatAction(s.ChatID, action))
+	return err
+}
@@ -0,0 +1,431 @@
+package tgr
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// defaultSessionTTL 会话数据默认保存时长
+const defaultSessionTTL = 24 * time.Hour
+
+// SessionData 会话数据，值为 JSON 编码，保证不同存储实现之间类型一致
+type SessionData map[string]json.RawMessage
+
+// SessionStore 会话存储。
+// Update 必须对同一个键原子地执行读取-修改-写入，
+// 这样 ListenWithContext 中并发处理同一会话的多个 worker 不会互相覆盖修改。
+// 同一进程内使用同一会话的处理链由路由器串行执行；多个进程共享存储时，
+// 不同键的修改会合并，同一个键的修改以最后写入为准。
+type SessionStore interface {
+	// Load 读取会话数据，不存在或已过期时返回空数据
+	Load(ctx context.Context, key string) (SessionData, error)
+	// Update 原子地修改会话数据并刷新过期时间，修改后数据为空时可以删除该键
+	Update(ctx context.Context, key string, ttl time.Duration, fn func(data SessionData)) error
+}
+
+// SessionKeyFunc 计算会话键，返回 false 表示该更新没有会话
+type SessionKeyFunc func(c *Context) (string, bool)
+
+// SessionPerChatUser 每个聊天中的每个用户一份会话（默认）
+func SessionPerChatUser(c *Context) (string, bool) {
+	key, err := c.stateKey()
+	return key, err == nil
+}
+
+// SessionPerUser 每个用户一份会话，跨聊天共享
+func SessionPerUser(c *Context) (string, bool) {
+	if from := c.Update.SentFrom(); from != nil {
+		return fmt.Sprintf("user:%d", from.ID), true
+	}
+	return "", false
+}
+
+// SessionPerChat 每个聊天一份会话，聊天内所有用户共享
+func SessionPerChat(c *Context) (string, bool) {
+	if chat := updateChat(c.Update); chat != nil {
+		return fmt.Sprintf("chat:%d", chat.ID), true
+	}
+	return "", false
+}
+
+// SetSessionStore 设置会话存储及会话保存时长，默认为内存存储、24 小时。
+// ttl <= 0 时使用默认值。
+func (t *TelegramRouter) SetSessionStore(store SessionStore, ttl time.Duration) *TelegramRouter {
+	if store == nil {
+		return t
+	}
+	if ttl <= 0 {
+		ttl = defaultSessionTTL
+	}
+	t.mu.Lock()
+	t.sessionStore = store
+	t.sessionTTL = ttl
+	t.mu.Unlock()
+	return t
+}
+
+// SetSessionKeyFunc 设置会话键的计算方式，如 SessionPerUser、SessionPerChat，默认 SessionPerChatUser
+func (t *TelegramRouter) SetSessionKeyFunc(fn SessionKeyFunc) *TelegramRouter {
+	if fn == nil {
+		return t
+	}
+	t.mu.Lock()
+	t.sessionKey = fn
+	t.mu.Unlock()
+	return t
+}
+
+// Session 会话数据包。
+// 在处理函数中首次调用 c.Session() 时加载，整个处理链结束后只把本次的修改写回存储。
+// 从加载到写回期间持有会话键的锁，同一会话的其他处理链在首次调用 c.Session() 时等待，
+// 因此先读后写（如计数器加一）不会丢失修改。
+type Session struct {
+	key     string
+	data    SessionData
+	changes map[string]json.RawMessage // nil 值表示删除
+	cleared bool
+	unlock  func() // 释放会话键的锁，写回后调用
+}
+
+// keyLocks 按键加锁，不同的键互不阻塞。零值可用。
+type keyLocks struct {
+	mu    sync.Mutex
+	locks map[string]*keyLock
+}
+
+// keyLock 单个键的锁及其持有和等待者数量，数量归零时从表中删除
+type keyLock struct {
+	mu   sync.Mutex
+	refs int
+}
+
+// lock 锁定键，返回对应的解锁函数
+func (l *keyLocks) lock(key string) (unlock func()) {
+	l.mu.Lock()
+	if l.locks == nil {
+		l.locks = make(map[string]*keyLock)
+	}
+	e, ok := l.locks[key]
+	if !ok {
+		e = &keyLock{}
+		l.locks[key] = e
+	}
+	e.refs++
+	l.mu.Unlock()
+
+	e.mu.Lock()
+	return func() {
+		e.mu.Unlock()
+		l.mu.Lock()
+		if e.refs--; e.refs == 0 {
+			delete(l.locks, key)
+		}
+		l.mu.Unlock()
+	}
+}
+
+// Session 返回当前更新的会话。
+// 同一次更新内多次调用返回同一个会话；更新没有会话键时返回不会保存的临时会话。
+func (c *Context) Session() *Session {
+	if c.session != nil {
+		return c.session
+	}
+	c.session = &Session{data: SessionData{}, changes: make(map[string]json.RawMessage)}
+	if c.router == nil {
+		return c.session
+	}
+	c.router.mu.RLock()
+	store, keyFn := c.router.sessionStore, c.router.sessionKey
+	c.router.mu.RUnlock()
+	key, ok := keyFn(c)
+	if store == nil || !ok {
+		return c.session
+	}
+	c.session.unlock = c.router.sessionLocks.lock(key)
+	data, err := store.Load(c, key)
+	if err != nil {
+		if c.Logger != nil {
+			c.Logger.Printf("加载会话失败: %v", err)
+		}
+		return c.session
+	}
+	c.session.key = key
+	if data != nil {
+		c.session.data = data
+	}
+	return c.session
+}
+
+// saveSession 将会话修改写回存储并释放会话键的锁
+func (t *TelegramRouter) saveSession(c *Context) {
+	s := c.session
+	if s != nil && s.unlock != nil {
+		defer s.unlock()
+	}
+	if s == nil || s.key == "" || (len(s.changes) == 0 && !s.cleared) {
+		return
+	}
+	t.mu.RLock()
+	store, ttl := t.sessionStore, t.sessionTTL
+	t.mu.RUnlock()
+	err := store.Update(c, s.key, ttl, func(data SessionData) {
+		if s.cleared {
+			clear(data)
+		}
+		for k, v := range s.changes {
+			if v == nil {
+				delete(data, k)
+			} else {
+				data[k] = v
+			}
+		}
+	})
+	if err != nil {
+		if t.Logger != nil {
+			t.Logger.Printf("保存会话失败: %v", err)
+		}
+		if t.errorReporter != nil {
+			t.errorReporter.Report(c, err, "session_key", s.key)
+		}
+	}
+}
+
+// Set 设置值，值会被编码为 JSON
+func (s *Session) Set(key string, value any) error {
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	s.data[key] = raw
+	s.changes[key] = raw
+	return nil
+}
+
+// Delete 删除值
+func (s *Session) Delete(key string) {
+	delete(s.data, key)
+	s.changes[key] = nil
+}
+
+// Clear 清空会话
+func (s *Session) Clear() {
+	clear(s.data)
+	clear(s.changes)
+	s.cleared = true
+}
+
+// Has 检查是否存在指定键
+func (s *Session) Has(key string) bool {
+	_, ok := s.data[key]
+	return ok
+}
+
+// Keys 返回所有键
+func (s *Session) Keys() []string {
+	keys := make([]string, 0, len(s.data))
+	for k := range s.data {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+// Bind 将值解码到 dst，键不存在时返回 false
+func (s *Session) Bind(key string, dst any) (bool, error) {
+	raw, ok := s.data[key]
+	if !ok {
+		return false, nil
+	}
+	return true, json.Unmarshal(raw, dst)
+}
+
+// GetString 获取字符串值，不存在或类型不符时返回默认值
+func (s *Session) GetString(key string, defaultValue ...string) string {
+	var v string
+	if ok, err := s.Bind(key, &v); !ok || err != nil {
+		return getDefaultValue(defaultValue)
+	}
+	return v
+}
+
+// GetInt 获取整数值，不存在或类型不符时返回默认值
+func (s *Session) GetInt(key string, defaultValue ...int) int {
+	var v int
+	if ok, err := s.Bind(key, &v); !ok || err != nil {
+		return getDefaultInt(defaultValue)
+	}
+	return v
+}
+
+// GetInt64 获取 int64 值，不存在或类型不符时返回默认值
+func (s *Session) GetInt64(key string, defaultValue ...int64) int64 {
+	var v int64
+	if ok, err := s.Bind(key, &v); !ok || err != nil {
+		if len(defaultValue) > 0 {
+			return defaultValue[0]
+		}
+		return 0
+	}
+	return v
+}
+
+// GetFloat64 获取浮点值，不存在或类型不符时返回默认值
+func (s *Session) GetFloat64(key string, defaultValue ...float64) float64 {
+	var v float64
+	if ok, err := s.Bind(key, &v); !ok || err != nil {
+		if len(defaultValue) > 0 {
+			return defaultValue[0]
+		}
+		return 0
+	}
+	return v
+}
+
+// GetBool 获取布尔值，不存在或类型不符时返回默认值
+func (s *Session) GetBool(key string, defaultValue ...bool) bool {
+	var v bool
+	if ok, err := s.Bind(key, &v); !ok || err != nil {
+		return getDefaultBool(defaultValue)
+	}
+	return v
+}
+
+// MemorySessionStore 基于内存的 SessionStore，过期会话会被自动清理
+type MemorySessionStore struct {
+	items *ttlMap
+}
+
+// NewMemorySessionStore 创建内存会话存储
+func NewMemorySessionStore() *MemorySessionStore {
+	return &MemorySessionStore{items: newTTLMap()}
+}
+
+// Load 读取会话数据的副本
+func (s *MemorySessionStore) Load(_ context.Context, key string) (SessionData, error) {
+	data := SessionData{}
+	if v, ok := s.items.get(key); ok {
+		for k, raw := range v.(SessionData) {
+			data[k] = raw
+		}
+	}
+	return data, nil
+}
+
+// Update 原子地修改会话数据
+func (s *MemorySessionStore) Update(_ context.Context, key string, ttl time.Duration, fn func(data SessionData)) error {
+	s.items.update(key, ttl, func(v any, ok bool) (any, bool) {
+		data := SessionData{}
+		if ok {
+			for k, raw := range v.(SessionData) {
+				data[k] = raw
+			}
+		}
+		fn(data)
+		return data, len(data) > 0
+	})
+	return nil
+}
+
+// FileSessionStore 基于 JSON 文件的 SessionStore，每个会话一个文件，无需外部服务。
+// 写入先写临时文件再重命名，同一进程内对同一个键的修改互斥。
+// 过期的会话在读取或修改时删除。
+type FileSessionStore struct {
+	dir   string
+	locks keyLocks
+}
+
+// fileSession 会话文件内容
+type fileSession struct {
+	Expires time.Time   `json:"expires"`
+	Data    SessionData `json:"data"`
+}
+
+// NewFileSessionStore 创建 JSON 文件会话存储，dir 不存在时自动创建
+func NewFileSessionStore(dir string) (*FileSessionStore, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+	return &FileSessionStore{dir: dir}, nil
+}
+
+// path 返回键对应的文件路径，键经 base64url 编码以保证文件名合法
+func (s *FileSessionStore) path(key string) string {
+	return filepath.Join(s.dir, base64.RawURLEncoding.EncodeToString([]byte(key))+".json")
+}
+
+// read 读取会话文件，不存在或已过期时返回空数据
+func (s *FileSessionStore) read(key string) (SessionData, error) {
+	path := s.path(key)
+	b, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return SessionData{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var fs fileSession
+	if err := json.Unmarshal(b, &fs); err != nil {
+		return nil, fmt.Errorf("decode session file %s: %w", path, err)
+	}
+	if !fs.Expires.IsZero() && time.Now().After(fs.Expires) {
+		_ = os.Remove(path)
+		return SessionData{}, nil
+	}
+	if fs.Data == nil {
+		fs.Data = SessionData{}
+	}
+	return fs.Data, nil
+}
+
+// Load 读取会话数据
+func (s *FileSessionStore) Load(_ context.Context, key string) (SessionData, error) {
+	unlock := s.locks.lock(key)
+	defer unlock()
+	return s.read(key)
+}
+
+// Update 原子地修改会话数据
+func (s *FileSessionStore) Update(_ context.Context, key string, ttl time.Duration, fn func(data SessionData)) error {
+	unlock := s.locks.lock(key)
+	defer unlock()
+	data, err := s.read(key)
+	if err != nil {
+		return err
+	}
+	fn(data)
+	path := s.path(key)
+	if len(data) == 0 {
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		return nil
+	}
+	fs := fileSession{Data: data}
+	if ttl > 0 {
+		fs.Expires = time.Now().Add(ttl)
+	}
+	b, err := json.Marshal(fs)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(s.dir, ".session-*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(b); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,67 @@
+package tgr
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSessionConcurrentUpdates(t *testing.T) {
+	router := NewTelegramRouter(nil)
+	router.Text(func(c *Context) {
+		s := c.Session()
+		count := s.GetInt("count")
+		time.Sleep(time.Millisecond) // 让并发的处理链交错
+		_ = s.Set("count", count+1)
+	})
+
+	const n = 50
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			router.HandleUpdate(textUpdate("hi"))
+		}()
+	}
+	wg.Wait()
+
+	data, err := router.sessionStore.Load(context.Background(), "1:7")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := (&Session{data: data}).GetInt("count")
+	if got != n {
+		t.Errorf("count %d, want %d", got, n)
+	}
+}
+
+func TestSessionDifferentKeysDoNotBlock(t *testing.T) {
+	router := NewTelegramRouter(nil)
+	holding, release := make(chan struct{}), make(chan struct{})
+	router.Text(func(c *Context) {
+		c.Session()
+		if c.Message.From.ID == 7 {
+			close(holding)
+			<-release
+		}
+	})
+
+	go router.HandleUpdate(textUpdate("slow"))
+	<-holding
+	defer close(release)
+
+	done := make(chan struct{})
+	go func() {
+		u := textUpdate("fast")
+		u.Message.From.ID = 47
+		router.HandleUpdate(u)
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("session of user 47 waited for the session of user 7")
+	}
+}
@@ -0,0 +1,185 @@
+package tgr
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// StateStore 会话状态存储，键由聊天 ID 与用户 ID 组成。
+// 默认使用内存实现 MemoryStateStore，持久化存储实现此接口即可。
+type StateStore interface {
+	// GetState 读取状态，不存在或已过期时 ok 为 false
+	GetState(ctx context.Context, key string) (state string, ok bool, err error)
+	// SetState 保存状态，ttl <= 0 表示永不过期
+	SetState(ctx context.Context, key, state string, ttl time.Duration) error
+	// DeleteState 删除状态
+	DeleteState(ctx context.Context, key string) error
+}
+
+// MemoryStateStore 基于内存的 StateStore
+type MemoryStateStore struct {
+	items *ttlMap
+}
+
+// NewMemoryStateStore 创建内存会话状态存储
+func NewMemoryStateStore() *MemoryStateStore {
+	return &MemoryStateStore{items: newTTLMap()}
+}
+
+// GetState 读取状态
+func (s *MemoryStateStore) GetState(_ context.Context, key string) (string, bool, error) {
+	v, ok := s.items.get(key)
+	if !ok {
+		return "", false, nil
+	}
+	return v.(string), true, nil
+}
+
+// SetState 保存状态
+func (s *MemoryStateStore) SetState(_ context.Context, key, state string, ttl time.Duration) error {
+	s.items.set(key, state, ttl)
+	return nil
+}
+
+// DeleteState 删除状态
+func (s *MemoryStateStore) DeleteState(_ context.Context, key string) error {
+	s.items.delete(key)
+	return nil
+}
+
+// SetStateStore 替换会话状态存储，默认为 MemoryStateStore
+func (t *TelegramRouter) SetStateStore(store StateStore) *TelegramRouter {
+	if store == nil {
+		return t
+	}
+	t.mu.Lock()
+	t.stateStore = store
+	t.mu.Unlock()
+	return t
+}
+
+// SetStateTimeout 设置指定状态的超时时间，超时后状态自动清除。
+// timeout <= 0 表示该状态永不过期（默认）。
+func (t *TelegramRouter) SetStateTimeout(state string, timeout time.Duration) *TelegramRouter {
+	t.mu.Lock()
+	if timeout > 0 {
+		t.stateTimeouts[state] = timeout
+	} else {
+		delete(t.stateTimeouts, state)
+	}
+	t.mu.Unlock()
+	return t
+}
+
+// State 创建会话状态作用域的路由分组。
+// 分组内注册的处理函数只在当前聊天中的用户处于该状态时执行，
+// 并且优先于同类型的普通处理函数：有状态处理函数匹配时，普通处理函数不再执行。
+//
+// 示例:
+//
+//	router.Command("signup", func(c *Context) {
+//	    _ = c.SetState("awaiting_email")
+//	    c.Reply("请输入邮箱").Send()
+//	})
+//	router.State("awaiting_email").Text(func(c *Context) {
+//	    _ = c.ClearState()
+//	    c.Reply("已记录邮箱：" + c.Message.Text).Send()
+//	})
+func (g *RouterGroup) State(state string) *RouterGroup {
+	return &RouterGroup{
+		router:   g.router,
+		parent:   g,
+		state:    state,
+		hasState: true,
+	}
+}
+
+// stateKey 会话状态键："<chatID>:<userID>"
+func (c *Context) stateKey() (string, error) {
+	var chatID, userID int64
+	if chat := updateChat(c.Update); chat != nil {
+		chatID = chat.ID
+	}
+	if from := c.Update.SentFrom(); from != nil {
+		userID = from.ID
+	}
+	if chatID == 0 && userID == 0 {
+		return "", fmt.Errorf("no chat or user for state")
+	}
+	return fmt.Sprintf("%d:%d", chatID, userID), nil
+}
+
+// stateStore 返回路由器的会话状态存储
+func (c *Context) stateStore() StateStore {
+	if c.router == nil {
+		return nil
+	}
+	c.router.mu.RLock()
+	defer c.router.mu.RUnlock()
+	return c.router.stateStore
+}
+
+// State 返回当前聊天中用户的会话状态，没有状态时返回空字符串。
+// 同一次更新内只从存储读取一次。
+func (c *Context) State() string {
+	if c.state != nil {
+		return *c.state
+	}
+	state := ""
+	c.state = &state
+	store := c.stateStore()
+	key, err := c.stateKey()
+	if store == nil || err != nil {
+		return ""
+	}
+	s, ok, err := store.GetState(c, key)
+	if err != nil {
+		if c.Logger != nil {
+			c.Logger.Printf("读取会话状态失败: %v", err)
+		}
+		return ""
+	}
+	if ok {
+		state = s
+	}
+	return state
+}
+
+// SetState 设置当前聊天中用户的会话状态，超时时间由 SetStateTimeout 决定
+func (c *Context) SetState(state string) error {
+	store := c.stateStore()
+	if store == nil {
+		return fmt.Errorf("no state store")
+	}
+	key, err := c.stateKey()
+	if err != nil {
+		return err
+	}
+	c.router.mu.RLock()
+	timeout := c.router.stateTimeouts[state]
+	c.router.mu.RUnlock()
+	if err := store.SetState(c, key, state, timeout); err != nil {
+		return err
+	}
+	c.state = &state
+	return nil
+}
+
+// ClearState 清除当前聊天中用户的会话状态
+func (c *Context) ClearState() error {
+	store := c.stateStore()
+	if store == nil {
+		return fmt.Errorf("no state store")
+	}
+	key, err := c.stateKey()
+	if err != nil {
+		return err
+	}
+	if err := store.DeleteState(c, key); err != nil {
+		return err
+	}
+	state := ""
+	c.state = &state
+	return nil
+}
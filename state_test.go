@@ -0,0 +1,194 @@
+package tgr
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// fakeStateStore 记录调用的 StateStore
+type fakeStateStore struct {
+	states map[string]string
+	ttls   map[string]time.Duration
+	gets   int
+	err    error // 非 nil 时所有操作返回该错误
+}
+
+func newFakeStateStore() *fakeStateStore {
+	return &fakeStateStore{states: map[string]string{}, ttls: map[string]time.Duration{}}
+}
+
+func (s *fakeStateStore) GetState(_ context.Context, key string) (string, bool, error) {
+	s.gets++
+	if s.err != nil {
+		return "", false, s.err
+	}
+	state, ok := s.states[key]
+	return state, ok, nil
+}
+
+func (s *fakeStateStore) SetState(_ context.Context, key, state string, ttl time.Duration) error {
+	if s.err != nil {
+		return s.err
+	}
+	s.states[key], s.ttls[key] = state, ttl
+	return nil
+}
+
+func (s *fakeStateStore) DeleteState(_ context.Context, key string) error {
+	if s.err != nil {
+		return s.err
+	}
+	delete(s.states, key)
+	return nil
+}
+
+// userText 构造指定用户发送的文本消息
+func userText(userID int64, text string) *tgbotapi.Update {
+	u := textUpdate(text)
+	u.Message.From.ID = userID
+	return u
+}
+
+func TestStateScopedRoutes(t *testing.T) {
+	router := NewTelegramRouter(nil)
+	rec := &recorder{}
+	router.Command("signup", func(c *Context) {
+		if err := c.SetState("awaiting_email"); err != nil {
+			t.Error(err)
+		}
+		rec.log = append(rec.log, "signup:"+c.State())
+	})
+	router.State("awaiting_email").Text(func(c *Context) {
+		rec.log = append(rec.log, "email:"+c.Message.Text)
+		if err := c.ClearState(); err != nil {
+			t.Error(err)
+		}
+		if c.State() != "" {
+			t.Errorf("state %q after ClearState", c.State())
+		}
+	})
+	router.State("other").Text(rec.handler("other"))
+	router.Text(func(c *Context) { rec.log = append(rec.log, "text:"+c.Message.Text) })
+
+	router.HandleUpdate(userText(7, "before"))
+	router.HandleUpdate(commandUpdate("/signup"))
+	router.HandleUpdate(userText(8, "another user")) // 状态按聊天与用户区分
+	router.HandleUpdate(userText(7, "a@b.c"))
+	router.HandleUpdate(userText(7, "after"))
+
+	want := []string{"text:before", "signup:awaiting_email", "text:another user", "email:a@b.c", "text:after"}
+	if !reflect.DeepEqual(rec.log, want) {
+		t.Errorf("ran %v, want %v", rec.log, want)
+	}
+}
+
+func TestStateGroupScope(t *testing.T) {
+	tests := []struct {
+		name  string
+		state string
+		want  []string
+	}{
+		{name: "no state", want: []string{"plain"}},
+		{name: "matching state", state: "s", want: []string{"state", "nested"}},
+		{name: "other state", state: "x", want: []string{"plain"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := NewTelegramRouter(nil)
+			rec := &recorder{}
+			router.Command("go", rec.handler("plain"))
+			s := router.State("s")
+			s.Command("go", rec.handler("state"))
+			// 状态分组内的中间件与嵌套分组同样受状态限制
+			s.Group(func(c *Context) { c.Next() }).Command("go", rec.handler("nested"))
+			if tt.state != "" {
+				if err := router.stateStore.SetState(context.Background(), "1:7", tt.state, 0); err != nil {
+					t.Fatal(err)
+				}
+			}
+			router.HandleUpdate(commandUpdate("/go"))
+			if !reflect.DeepEqual(rec.log, tt.want) {
+				t.Errorf("ran %v, want %v", rec.log, tt.want)
+			}
+		})
+	}
+}
+
+func TestStateTimeout(t *testing.T) {
+	router := NewTelegramRouter(nil).SetStateTimeout("short", 10*time.Millisecond)
+	var states []string
+	router.Command("set", func(c *Context) {
+		if err := c.SetState(c.Message.CommandArguments()); err != nil {
+			t.Error(err)
+		}
+	})
+	router.Text(func(c *Context) { states = append(states, c.State()) })
+
+	router.HandleUpdate(commandUpdate("/set short"))
+	router.HandleUpdate(textUpdate("now"))
+	time.Sleep(20 * time.Millisecond)
+	router.HandleUpdate(textUpdate("later"))
+
+	router.HandleUpdate(commandUpdate("/set long"))
+	time.Sleep(20 * time.Millisecond)
+	router.HandleUpdate(textUpdate("later"))
+
+	if want := []string{"short", "", "long"}; !reflect.DeepEqual(states, want) {
+		t.Errorf("states %q, want %q", states, want)
+	}
+}
+
+func TestCustomStateStore(t *testing.T) {
+	store := newFakeStateStore()
+	router := NewTelegramRouter(nil).SetStateStore(store).SetStateTimeout("s", time.Minute)
+	router.SetStateStore(nil) // nil 不替换当前存储
+	router.Command("set", func(c *Context) {
+		if err := c.SetState("s"); err != nil {
+			t.Error(err)
+		}
+	})
+	var got []string
+	router.Text(func(c *Context) { got = append(got, c.State(), c.State()) })
+
+	router.HandleUpdate(commandUpdate("/set"))
+	if store.states["1:7"] != "s" || store.ttls["1:7"] != time.Minute {
+		t.Fatalf("store has %v with ttls %v", store.states, store.ttls)
+	}
+	store.gets = 0
+	router.HandleUpdate(textUpdate("hi"))
+	if !reflect.DeepEqual(got, []string{"s", "s"}) {
+		t.Errorf("states %q", got)
+	}
+	if store.gets != 1 {
+		t.Errorf("GetState called %d times in one update, want 1", store.gets)
+	}
+
+	// 存储出错时读取返回空状态，写入返回错误
+	store.err = errors.New("down")
+	c := router.newContext(context.Background(), textUpdate("hi"))
+	if s := c.State(); s != "" {
+		t.Errorf("State() = %q with failing store", s)
+	}
+	if err := c.SetState("x"); !errors.Is(err, store.err) {
+		t.Errorf("SetState() = %v, want store error", err)
+	}
+	if err := c.ClearState(); !errors.Is(err, store.err) {
+		t.Errorf("ClearState() = %v, want store error", err)
+	}
+}
+
+func TestSetStateWithoutChatOrUser(t *testing.T) {
+	router := NewTelegramRouter(nil)
+	c := router.newContext(context.Background(), &tgbotapi.Update{Poll: &tgbotapi.Poll{ID: "p"}})
+	if err := c.SetState("s"); err == nil {
+		t.Error("SetState succeeded without chat or user")
+	}
+	if c.State() != "" {
+		t.Errorf("State() = %q", c.State())
+	}
+}
@@ -0,0 +1,90 @@
+package tgr
+
+import (
+	"sync"
+	"time"
+)
+
+// ttlMap 带过期时间的并发安全内存映射，供内置的内存存储复用。
+// 过期数据在读取时删除，写入时每分钟最多整体清理一次。
+type ttlMap struct {
+	mu        sync.Mutex
+	items     map[string]ttlItem
+	lastSweep time.Time
+}
+
+type ttlItem struct {
+	value   any
+	expires time.Time // 零值表示永不过期
+}
+
+func newTTLMap() *ttlMap {
+	return &ttlMap{items: make(map[string]ttlItem)}
+}
+
+func (i ttlItem) expired(now time.Time) bool {
+	return !i.expires.IsZero() && now.After(i.expires)
+}
+
+// get 读取未过期的值
+func (m *ttlMap) get(key string) (any, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	item, ok := m.items[key]
+	if !ok {
+		return nil, false
+	}
+	if item.expired(time.Now()) {
+		delete(m.items, key)
+		return nil, false
+	}
+	return item.value, true
+}
+
+// set 写入值，ttl <= 0 表示永不过期
+func (m *ttlMap) set(key string, value any, ttl time.Duration) {
+	now := time.Now()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if now.Sub(m.lastSweep) > time.Minute {
+		for k, item := range m.items {
+			if item.expired(now) {
+				delete(m.items, k)
+			}
+		}
+		m.lastSweep = now
+	}
+	item := ttlItem{value: value}
+	if ttl > 0 {
+		item.expires = now.Add(ttl)
+	}
+	m.items[key] = item
+}
+
+// delete 删除值
+func (m *ttlMap) delete(key string) {
+	m.mu.Lock()
+	delete(m.items, key)
+	m.mu.Unlock()
+}
+
+// update 在锁内原子地读取-修改-写入，fn 返回 keep 为 false 时删除该键
+func (m *ttlMap) update(key string, ttl time.Duration, fn func(value any, ok bool) (newValue any, keep bool)) {
+	now := time.Now()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	item, ok := m.items[key]
+	if ok && item.expired(now) {
+		ok = false
+	}
+	value, keep := fn(item.value, ok)
+	if !keep {
+		delete(m.items, key)
+		return
+	}
+	item = ttlItem{value: value}
+	if ttl > 0 {
+		item.expires = now.Add(ttl)
+	}
+	m.items[key] = item
+}
@@ -0,0 +1,322 @@
+package tgr
+
+import (
+	"fmt"
+	"regexp"
+	"regexp/syntax"
+	"strings"
+)
+
+// RouteIssueKind 路由问题类型
+type RouteIssueKind string
+
+const (
+	// IssueUnreachable 路由永远不会被执行
+	IssueUnreachable RouteIssueKind = "unreachable"
+	// IssueDuplicate 路由与另一个路由匹配完全相同的更新，且更早的路由执行后停止分发
+	IssueDuplicate RouteIssueKind = "duplicate"
+	// IssueShadowed 路由匹配的更新总是先被另一个路由处理并停止分发
+	IssueShadowed RouteIssueKind = "shadowed"
+)
+
+// RouteIssue Validate 发现的路由问题
+type RouteIssue struct {
+	Kind    RouteIssueKind `json:"kind"`
+	Route   RouteInfo      `json:"route"`
+	Other   *RouteInfo     `json:"other,omitempty"` // 与之重复或遮蔽它的路由
+	Message string         `json:"message"`
+}
+
+// String 返回问题的可读描述
+func (i RouteIssue) String() string {
+	s := fmt.Sprintf("%s: %s %q (#%d): %s", i.Kind, i.Route.Kind, i.Route.Pattern, i.Route.Order, i.Message)
+	if i.Other != nil {
+		s += fmt.Sprintf(" (see %s %q #%d)", i.Other.Kind, i.Other.Pattern, i.Other.Order)
+	}
+	return s
+}
+
+// nonMessageKinds 不经过消息分发的路由类型，频道分组中的这些路由永远不会执行
+var nonMessageKinds = map[string]bool{
+	"update": true, "on": true, "callback": true, "noCallback": true,
+	"inlineQuery": true, "chosenInlineResult": true,
+	"poll": true, "pollType": true, "regularPoll": true, "pollAnswer": true,
+	"preCheckoutQuery": true, "shippingQuery": true,
+	"myChatMember": true, "chatMember": true,
+	"editedMessage": true, "editedChannelPost": true,
+}
+
+// routeList 路由所在的处理器列表，同一列表中的路由按注册顺序依次匹配
+var routeList = map[string]string{
+	"textMatch":     "text",
+	"textRegex":     "text",
+	"locationRange": "location",
+	"documentType":  "document",
+}
+
+// Validate 检查组合后的路由表，报告永远不会执行、重复以及被遮蔽的路由。
+// 不会修改路由；可以在启动前调用，或通过 SetStrictRoutes 在启动时自动检查。
+//
+// 示例:
+//
+//	for _, issue := range router.Validate() {
+//	    log.Println(issue)
+//	}
+func (t *TelegramRouter) Validate() []RouteIssue {
+	rt := t.snapshot()
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	var issues []RouteIssue
+	report := func(kind RouteIssueKind, r, other *route, format string, args ...any) {
+		issue := RouteIssue{Kind: kind, Route: t.routeInfo(r), Message: fmt.Sprintf(format, args...)}
+		if other != nil {
+			info := t.routeInfo(other)
+			issue.Other = &info
+		}
+		issues = append(issues, issue)
+	}
+
+	// 分发顺序导致永远不会执行的路由
+	for _, r := range t.registry {
+		switch {
+		case r.kind == "liveLocation":
+			report(IssueUnreachable, r, nil, "location messages are dispatched to Location routes before live locations are checked")
+		case r.kind == "animation":
+			report(IssueUnreachable, r, nil, "animation messages also carry a document and are dispatched to Document routes first")
+		case r.group.scope().channel && nonMessageKinds[r.kind]:
+			report(IssueUnreachable, r, nil, "channel groups only receive channel posts")
+		}
+	}
+
+	// 匹配相同更新的重复路由：只有更早的路由执行后总是停止分发时，后注册的路由才永远不会执行；
+	// 回调路由由前缀树在组合时检测
+	seen := make(map[string]*route)
+	dups := make(map[*route]bool)
+	for _, r := range t.registry {
+		if r.pattern == "" || r.kind == "callback" || r.kind == "on" {
+			continue
+		}
+		key := fmt.Sprintf("%s\x00%s\x00%+v", r.kind, routeName(r), r.group.scope())
+		prev, ok := seen[key]
+		if !ok {
+			if stopsDispatch(r.kind, t.policyFor(r.group)) {
+				seen[key] = r
+			}
+			continue
+		}
+		report(IssueDuplicate, r, prev, "matches the same updates as an earlier route that stops dispatch")
+		dups[r] = true
+	}
+	for _, c := range rt.callbackConflictsC {
+		// 默认只执行优先级最高的回调路由，开启 SetCallbackFallThrough 后依次执行
+		prev := c[1]
+		if dups[c[0].route] || prev.policy != FirstMatch && (prev.policy != DispatchDefault || t.callbackFallThrough) {
+			continue
+		}
+		report(IssueDuplicate, c[0].route, prev.route, "matches the same callback data as an earlier route that stops dispatch")
+		dups[c[0].route] = true
+	}
+
+	// 正则命令路由在命令路由之后匹配，只匹配已注册命令名的正则被命令路由遮蔽
+	commands := make(map[string]*route)
+	for _, r := range t.registry {
+		if p := t.policyFor(r.group); r.kind == "command" && (p == DispatchDefault || p == FirstMatch) {
+			key := fmt.Sprintf("%s\x00%+v", routeName(r), r.group.scope())
+			if _, ok := commands[key]; !ok {
+				commands[key] = r
+			}
+		}
+	}
+
+	// 被遮蔽的路由：同一列表中更早注册的无条件 FirstMatch 路由，或前缀更短的 FirstMatch TextMatch
+	firsts := make(map[string][]*route)
+	for _, r := range t.registry {
+		list, ok := routeList[r.kind]
+		if !ok {
+			list = r.kind
+		}
+		if dups[r] || r.kind == "command" || r.kind == "on" || r.kind == "callback" || r.kind == "update" {
+			continue
+		}
+		key := fmt.Sprintf("%s\x00%+v", list, r.group.scope())
+		shadowed := false
+		if r.kind == "commandRegex" {
+			if names, ok := regexLiterals(r.pattern); ok {
+				var cmd *route
+				for _, name := range names {
+					if cmd = commands[fmt.Sprintf("%s\x00%+v", name, r.group.scope())]; cmd == nil {
+						break
+					}
+				}
+				if cmd != nil {
+					report(IssueShadowed, r, cmd, "every command it matches is handled first by a Command route that stops dispatch")
+					shadowed = true
+				}
+			}
+		}
+		for _, prev := range firsts[key] {
+			if shadowed {
+				break
+			}
+			if shadows(prev, r) {
+				report(IssueShadowed, r, prev, "an earlier route always handles these updates first and stops dispatch")
+				break
+			}
+		}
+		if stopsDispatch(r.kind, t.policyFor(r.group)) {
+			firsts[key] = append(firsts[key], r)
+		}
+	}
+	return issues
+}
+
+// stopsDispatch 判断路由执行后是否总是停止同一类更新的后续路由：
+// FirstMatch，以及默认策略下第一个匹配即停止的深度链接、正则命令与谓词路由
+func stopsDispatch(kind string, policy DispatchPolicy) bool {
+	switch policy {
+	case FirstMatch:
+		return true
+	case DispatchDefault:
+		return kind == "startPayload" || kind == "commandRegex" || kind == "on"
+	}
+	return false
+}
+
+// routeName 返回用于比较的路由模式，命令路由只取命令名
+func routeName(r *route) string {
+	if r.kind == "command" {
+		name, _, _ := strings.Cut(strings.TrimPrefix(r.pattern, "/"), " ")
+		return name
+	}
+	return r.pattern
+}
+
+// shadows 判断执行后停止分发的 prev 是否总是先于 r 匹配
+func shadows(prev, r *route) bool {
+	switch {
+	case prev.kind == "startPayload":
+		sr, _ := compileStartPattern(prev.pattern)
+		return sr.MatchString(r.pattern)
+	case prev.kind == "textMatch" && r.kind == "textMatch":
+		return strings.HasPrefix(r.pattern, prev.pattern)
+	case prev.kind == "commandRegex":
+		// 正则之间只能比较固定命令名：r 匹配的每个命令名 prev 都匹配
+		names, ok := regexLiterals(r.pattern)
+		if !ok {
+			return false
+		}
+		re := regexp.MustCompile(prev.pattern)
+		for _, name := range names {
+			if !re.MatchString(name) {
+				return false
+			}
+		}
+		return true
+	default:
+		return prev.match == nil
+	}
+}
+
+// maxRegexLiterals regexLiterals 展开的字符串数量上限
+const maxRegexLiterals = 32
+
+// regexLiterals 返回首尾锚定的正则能匹配的全部字符串，如 "^(start|help)$" 返回 start 与 help；
+// 正则没有锚定、含重复或可匹配的字符串过多时 ok 为 false
+func regexLiterals(pattern string) (literals []string, ok bool) {
+	re, err := syntax.Parse(pattern, syntax.Perl)
+	if err != nil {
+		return nil, false
+	}
+	re = re.Simplify()
+	if re.Op != syntax.OpConcat || len(re.Sub) < 2 {
+		return nil, false
+	}
+	first, last := re.Sub[0].Op, re.Sub[len(re.Sub)-1].Op
+	if first != syntax.OpBeginText || last != syntax.OpEndText {
+		return nil, false
+	}
+	return expandRegex(&syntax.Regexp{Op: syntax.OpConcat, Sub: re.Sub[1 : len(re.Sub)-1]})
+}
+
+// expandRegex 展开不含重复的正则能匹配的全部字符串
+func expandRegex(re *syntax.Regexp) ([]string, bool) {
+	switch re.Op {
+	case syntax.OpEmptyMatch:
+		return []string{""}, true
+	case syntax.OpLiteral:
+		if re.Flags&syntax.FoldCase != 0 {
+			return nil, false
+		}
+		return []string{string(re.Rune)}, true
+	case syntax.OpCharClass:
+		var out []string
+		for i := 0; i+1 < len(re.Rune); i += 2 {
+			for r := re.Rune[i]; r <= re.Rune[i+1]; r++ {
+				if out = append(out, string(r)); len(out) > maxRegexLiterals {
+					return nil, false
+				}
+			}
+		}
+		return out, true
+	case syntax.OpCapture:
+		return expandRegex(re.Sub[0])
+	case syntax.OpQuest:
+		sub, ok := expandRegex(re.Sub[0])
+		return append(sub, ""), ok && len(sub) < maxRegexLiterals
+	case syntax.OpAlternate:
+		var out []string
+		for _, s := range re.Sub {
+			sub, ok := expandRegex(s)
+			if out = append(out, sub...); !ok || len(out) > maxRegexLiterals {
+				return nil, false
+			}
+		}
+		return out, true
+	case syntax.OpConcat:
+		out := []string{""}
+		for _, s := range re.Sub {
+			sub, ok := expandRegex(s)
+			if !ok || len(out)*len(sub) > maxRegexLiterals {
+				return nil, false
+			}
+			next := make([]string, 0, len(out)*len(sub))
+			for _, prefix := range out {
+				for _, suffix := range sub {
+					next = append(next, prefix+suffix)
+				}
+			}
+			out = next
+		}
+		return out, true
+	}
+	return nil, false
+}
+
+// SetStrictRoutes 开启后，启动（Listen、ListenWithContext 以及挂载 Webhook）时调用 Validate，
+// 发现任何问题都会 panic，便于在部署前暴露注册错误。默认关闭。
+func (t *TelegramRouter) SetStrictRoutes(enabled bool) *TelegramRouter {
+	t.mu.Lock()
+	t.strictRoutes = enabled
+	t.mu.Unlock()
+	return t
+}
+
+// checkRoutes 严格模式下校验路由，发现问题时 panic
+func (t *TelegramRouter) checkRoutes() {
+	t.mu.RLock()
+	strict := t.strictRoutes
+	t.mu.RUnlock()
+	if !strict {
+		return
+	}
+	issues := t.Validate()
+	if len(issues) == 0 {
+		return
+	}
+	lines := make([]string, 0, len(issues))
+	for _, issue := range issues {
+		lines = append(lines, "  "+issue.String())
+	}
+	panic("tgr: invalid routes:\n" + strings.Join(lines, "\n"))
+}
@@ -0,0 +1,202 @@
+package tgr
+
+import (
+	"reflect"
+	"regexp"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	nop := func(*Context) {}
+	tests := []struct {
+		name  string
+		setup func(router *TelegramRouter)
+		want  []string // "<kind> <route kind> <pattern>"
+	}{
+		{
+			name: "repeated command under default policy",
+			setup: func(router *TelegramRouter) {
+				router.Command("a", nop)
+				router.Command("a :id:int", nop)
+			},
+		},
+		{
+			name: "repeated command under FirstMatch",
+			setup: func(router *TelegramRouter) {
+				router.SetDispatchPolicy(FirstMatch)
+				router.Command("a", nop)
+				router.Command("/a :id:int", nop)
+			},
+			want: []string{"duplicate command /a :id:int"},
+		},
+		{
+			name: "repeated command after a FirstMatch route",
+			setup: func(router *TelegramRouter) {
+				router.Command("a", nop)
+				router.WithDispatchPolicy(FirstMatch).Command("a", nop)
+				router.Command("a", nop)
+			},
+			want: []string{"duplicate command a"},
+		},
+		{
+			name: "repeated command under AllMatch",
+			setup: func(router *TelegramRouter) {
+				router.SetDispatchPolicy(AllMatch)
+				router.Command("a", nop)
+				router.Command("a", nop)
+			},
+		},
+		{
+			name: "repeated text match under default policy",
+			setup: func(router *TelegramRouter) {
+				router.TextMatch("hi", nop)
+				router.TextMatch("hi", nop)
+			},
+		},
+		{
+			name: "repeated command regex stops under default policy",
+			setup: func(router *TelegramRouter) {
+				router.CommandRegex(regexp.MustCompile(`^a\d+$`), nop)
+				router.CommandRegex(regexp.MustCompile(`^a\d+$`), nop)
+			},
+			want: []string{`duplicate commandRegex ^a\d+$`},
+		},
+		{
+			name: "repeated start payload",
+			setup: func(router *TelegramRouter) {
+				router.StartPayload("ref-:code", nop)
+				router.StartPayload("ref-:code", nop)
+			},
+			want: []string{"duplicate startPayload ref-:code"},
+		},
+		{
+			name: "same command in different scopes",
+			setup: func(router *TelegramRouter) {
+				router.SetDispatchPolicy(FirstMatch)
+				router.Command("a", nop)
+				router.Private().Command("a", nop)
+				router.State("s").Command("a", nop)
+			},
+		},
+		{
+			name: "conflicting callbacks",
+			setup: func(router *TelegramRouter) {
+				router.Callback("item/:id", nop)
+				router.Callback("item/:key", nop)
+			},
+			want: []string{"duplicate callback item/:key"},
+		},
+		{
+			name: "conflicting callbacks with fall-through",
+			setup: func(router *TelegramRouter) {
+				router.SetCallbackFallThrough(true)
+				router.Callback("item/:id", nop)
+				router.Callback("item/:key", nop)
+			},
+		},
+		{
+			name: "command regex shadowed by command",
+			setup: func(router *TelegramRouter) {
+				router.CommandRegex(regexp.MustCompile(`^(start|help)$`), nop)
+				router.Command("start", nop)
+				router.Command("help", nop)
+			},
+			want: []string{"shadowed commandRegex ^(start|help)$"},
+		},
+		{
+			name: "command regex shadowed under FirstMatch",
+			setup: func(router *TelegramRouter) {
+				router.SetDispatchPolicy(FirstMatch)
+				router.Command("a", nop)
+				router.CommandRegex(regexp.MustCompile(`^a$`), nop)
+			},
+			want: []string{"shadowed commandRegex ^a$"},
+		},
+		{
+			name: "command regex matching other commands",
+			setup: func(router *TelegramRouter) {
+				router.Command("start", nop)
+				router.CommandRegex(regexp.MustCompile(`^(start|help)$`), nop)
+				router.CommandRegex(regexp.MustCompile(`^start`), nop)
+				router.CommandRegex(regexp.MustCompile(`(?i)^start$`), nop)
+			},
+		},
+		{
+			name: "command regex after AllMatch command",
+			setup: func(router *TelegramRouter) {
+				router.WithDispatchPolicy(AllMatch).Command("a", nop)
+				router.CommandRegex(regexp.MustCompile(`^a$`), nop)
+			},
+		},
+		{
+			name: "command regex shadowed by command regex",
+			setup: func(router *TelegramRouter) {
+				router.CommandRegex(regexp.MustCompile(`^ad`), nop)
+				router.CommandRegex(regexp.MustCompile(`^ad(d|min)$`), nop)
+				router.CommandRegex(regexp.MustCompile(`^ban$`), nop)
+			},
+			want: []string{"shadowed commandRegex ^ad(d|min)$"},
+		},
+		{
+			name: "text shadowed under FirstMatch",
+			setup: func(router *TelegramRouter) {
+				router.SetDispatchPolicy(FirstMatch)
+				router.TextMatch("he", nop)
+				router.TextMatch("hello", nop)
+				router.TextMatch("hi", nop)
+				router.Text(nop)
+				router.TextMatch("yo", nop)
+			},
+			want: []string{"shadowed textMatch hello", "shadowed textMatch yo"},
+		},
+		{
+			name: "unreachable routes",
+			setup: func(router *TelegramRouter) {
+				router.LiveLocation(nop)
+				router.Animation(nop)
+				router.Channel().Callback("x", nop)
+				router.Channel().Text(nop)
+			},
+			want: []string{"unreachable liveLocation ", "unreachable animation ", "unreachable callback x"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := NewTelegramRouter(nil)
+			router.Logger = nil
+			tt.setup(router)
+			var got []string
+			for _, issue := range router.Validate() {
+				got = append(got, string(issue.Kind)+" "+issue.Route.Kind+" "+issue.Route.Pattern)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("issues %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStrictRoutes(t *testing.T) {
+	nop := func(*Context) {}
+	tests := []struct {
+		name      string
+		policy    DispatchPolicy
+		wantPanic bool
+	}{
+		{name: "repeated commands run under default policy", policy: DispatchDefault},
+		{name: "repeated commands under FirstMatch", policy: FirstMatch, wantPanic: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := NewTelegramRouter(nil).SetDispatchPolicy(tt.policy).SetStrictRoutes(true)
+			router.Command("a", nop)
+			router.Command("a", nop)
+			defer func() {
+				if r := recover(); (r != nil) != tt.wantPanic {
+					t.Errorf("panic %v, want panic %v", r, tt.wantPanic)
+				}
+			}()
+			router.checkRoutes()
+		})
+	}
+}